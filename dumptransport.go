@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"github.com/greenboxal/emv-kernel/emv"
+	"github.com/greenboxal/emv-kernel/tlv"
+)
+
+// dumpTransport prints the data objects of every card response.
+type dumpTransport struct {
+	emv.Transport
+}
+
+func (dt *dumpTransport) Transmit(command []byte) ([]byte, error) {
+	res, err := dt.Transport.Transmit(command)
+
+	if err != nil || len(res) < 2 {
+		return res, err
+	}
+
+	if dump, err := tlv.Dump(res[:len(res)-2]); err == nil {
+		fmt.Print(dump)
+	}
+
+	return res, nil
+}
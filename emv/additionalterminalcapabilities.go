@@ -0,0 +1,100 @@
+package emv
+
+// AdditionalTerminalCapabilities holds the Additional Terminal Capabilities (9F40).
+type AdditionalTerminalCapabilities uint64
+
+const (
+	AddCapCash           AdditionalTerminalCapabilities = 1 << 39
+	AddCapGoods          AdditionalTerminalCapabilities = 1 << 38
+	AddCapServices       AdditionalTerminalCapabilities = 1 << 37
+	AddCapCashback       AdditionalTerminalCapabilities = 1 << 36
+	AddCapInquiry        AdditionalTerminalCapabilities = 1 << 35
+	AddCapTransfer       AdditionalTerminalCapabilities = 1 << 34
+	AddCapPayment        AdditionalTerminalCapabilities = 1 << 33
+	AddCapAdministrative AdditionalTerminalCapabilities = 1 << 32
+	AddCapCashDeposit    AdditionalTerminalCapabilities = 1 << 31
+
+	AddCapNumericKeys    AdditionalTerminalCapabilities = 1 << 23
+	AddCapAlphabeticKeys AdditionalTerminalCapabilities = 1 << 22
+	AddCapCommandKeys    AdditionalTerminalCapabilities = 1 << 21
+	AddCapFunctionKeys   AdditionalTerminalCapabilities = 1 << 20
+
+	AddCapPrintAttendant    AdditionalTerminalCapabilities = 1 << 15
+	AddCapPrintCardholder   AdditionalTerminalCapabilities = 1 << 14
+	AddCapDisplayAttendant  AdditionalTerminalCapabilities = 1 << 13
+	AddCapDisplayCardholder AdditionalTerminalCapabilities = 1 << 12
+	AddCapCodeTable10       AdditionalTerminalCapabilities = 1 << 9
+	AddCapCodeTable9        AdditionalTerminalCapabilities = 1 << 8
+
+	AddCapCodeTable8 AdditionalTerminalCapabilities = 1 << 7
+	AddCapCodeTable7 AdditionalTerminalCapabilities = 1 << 6
+	AddCapCodeTable6 AdditionalTerminalCapabilities = 1 << 5
+	AddCapCodeTable5 AdditionalTerminalCapabilities = 1 << 4
+	AddCapCodeTable4 AdditionalTerminalCapabilities = 1 << 3
+	AddCapCodeTable3 AdditionalTerminalCapabilities = 1 << 2
+	AddCapCodeTable2 AdditionalTerminalCapabilities = 1 << 1
+	AddCapCodeTable1 AdditionalTerminalCapabilities = 1 << 0
+)
+
+var additionalTerminalCapabilitiesNames = []bitName{
+	{uint64(AddCapCash), "Cash"},
+	{uint64(AddCapGoods), "Goods"},
+	{uint64(AddCapServices), "Services"},
+	{uint64(AddCapCashback), "Cashback"},
+	{uint64(AddCapInquiry), "Inquiry"},
+	{uint64(AddCapTransfer), "Transfer"},
+	{uint64(AddCapPayment), "Payment"},
+	{uint64(AddCapAdministrative), "Administrative"},
+	{uint64(AddCapCashDeposit), "Cash deposit"},
+	{uint64(AddCapNumericKeys), "Numeric keys"},
+	{uint64(AddCapAlphabeticKeys), "Alphabetic and special characters keys"},
+	{uint64(AddCapCommandKeys), "Command keys"},
+	{uint64(AddCapFunctionKeys), "Function keys"},
+	{uint64(AddCapPrintAttendant), "Print, attendant"},
+	{uint64(AddCapPrintCardholder), "Print, cardholder"},
+	{uint64(AddCapDisplayAttendant), "Display, attendant"},
+	{uint64(AddCapDisplayCardholder), "Display, cardholder"},
+	{uint64(AddCapCodeTable10), "Code table 10"},
+	{uint64(AddCapCodeTable9), "Code table 9"},
+	{uint64(AddCapCodeTable8), "Code table 8"},
+	{uint64(AddCapCodeTable7), "Code table 7"},
+	{uint64(AddCapCodeTable6), "Code table 6"},
+	{uint64(AddCapCodeTable5), "Code table 5"},
+	{uint64(AddCapCodeTable4), "Code table 4"},
+	{uint64(AddCapCodeTable3), "Code table 3"},
+	{uint64(AddCapCodeTable2), "Code table 2"},
+	{uint64(AddCapCodeTable1), "Code table 1"},
+}
+
+func (c *AdditionalTerminalCapabilities) Set(bits AdditionalTerminalCapabilities) {
+	*c |= bits
+}
+
+// Has reports whether any of bits is set.
+func (c AdditionalTerminalCapabilities) Has(bits AdditionalTerminalCapabilities) bool {
+	return c&bits != 0
+}
+
+func (c AdditionalTerminalCapabilities) Bytes() []byte {
+	return encodeBits(uint64(c), 5)
+}
+
+func (c AdditionalTerminalCapabilities) EncodeTlv() ([]byte, error) {
+	return c.Bytes(), nil
+}
+
+func (c *AdditionalTerminalCapabilities) DecodeTlv(data []byte) error {
+	value, err := decodeBits(data, 5)
+
+	if err != nil {
+		return err
+	}
+
+	*c = AdditionalTerminalCapabilities(value)
+
+	return nil
+}
+
+func (c AdditionalTerminalCapabilities) String() string {
+	return describeBits(uint64(c), additionalTerminalCapabilitiesNames)
+}
@@ -0,0 +1,55 @@
+package emv
+
+// AIP holds the Application Interchange Profile (82).
+type AIP uint64
+
+const (
+	AipSdaSupported           AIP = 1 << 14
+	AipDdaSupported           AIP = 1 << 13
+	AipCvmSupported           AIP = 1 << 12
+	AipTerminalRiskManagement AIP = 1 << 11
+	AipIssuerAuthentication   AIP = 1 << 10
+	AipCdaSupported           AIP = 1 << 8
+)
+
+var aipNames = []bitName{
+	{uint64(AipSdaSupported), "SDA supported"},
+	{uint64(AipDdaSupported), "DDA supported"},
+	{uint64(AipCvmSupported), "Cardholder verification is supported"},
+	{uint64(AipTerminalRiskManagement), "Terminal risk management is to be performed"},
+	{uint64(AipIssuerAuthentication), "Issuer authentication is supported"},
+	{uint64(AipCdaSupported), "CDA supported"},
+}
+
+func (a *AIP) Set(bits AIP) {
+	*a |= bits
+}
+
+// Has reports whether any of bits is set.
+func (a AIP) Has(bits AIP) bool {
+	return a&bits != 0
+}
+
+func (a AIP) Bytes() []byte {
+	return encodeBits(uint64(a), 2)
+}
+
+func (a AIP) EncodeTlv() ([]byte, error) {
+	return a.Bytes(), nil
+}
+
+func (a *AIP) DecodeTlv(data []byte) error {
+	value, err := decodeBits(data, 2)
+
+	if err != nil {
+		return err
+	}
+
+	*a = AIP(value)
+
+	return nil
+}
+
+func (a AIP) String() string {
+	return describeBits(uint64(a), aipNames)
+}
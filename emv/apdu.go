@@ -1,5 +1,7 @@
 package emv
 
+import "fmt"
+
 type Apdu struct {
 	Class       byte
 	Instruction byte
@@ -8,3 +10,37 @@ type Apdu struct {
 	Data        []byte
 	Expected    byte
 }
+
+// parseApdu parses a command APDU, as sent in the issuer scripts.
+func parseApdu(raw []byte) (*Apdu, error) {
+	if len(raw) < 4 {
+		return nil, fmt.Errorf("invalid command apdu")
+	}
+
+	apdu := &Apdu{
+		Class:       raw[0],
+		Instruction: raw[1],
+		P1:          raw[2],
+		P2:          raw[3],
+	}
+
+	switch {
+	case len(raw) == 4:
+	case len(raw) == 5:
+		apdu.Expected = raw[4]
+	default:
+		length := int(raw[4])
+
+		if length == 0 || len(raw) < 5+length || len(raw) > 6+length {
+			return nil, fmt.Errorf("invalid command apdu")
+		}
+
+		apdu.Data = raw[5 : 5+length]
+
+		if len(raw) == 6+length {
+			apdu.Expected = raw[5+length]
+		}
+	}
+
+	return apdu, nil
+}
@@ -1,6 +1,14 @@
 package emv
 
 type ApplicationConfig struct {
-	Aid []byte
-	Tac TacSet
+	Aid     []byte
+	Version []byte
+	Tac     TacSet
+
+	// Terminal risk management parameters, amounts are in the same unit
+	// as the transaction amount
+	FloorLimit          int
+	Threshold           int
+	TargetPercentage    int
+	MaxTargetPercentage int
 }
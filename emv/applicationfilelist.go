@@ -1,12 +1,12 @@
 package emv
 
-import "errors"
+import "github.com/greenboxal/emv-kernel/tlv"
 
 type ApplicationFileList []ApplicationFile
 
 func (afl *ApplicationFileList) DecodeTlv(data []byte) error {
 	if len(data)%4 != 0 {
-		return errors.New("len must be multiple of 4")
+		return &tlv.DecodeError{Offset: len(data) - len(data)%4, Err: tlv.ErrTruncated}
 	}
 
 	*afl = make(ApplicationFileList, len(data)/4)
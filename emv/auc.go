@@ -0,0 +1,64 @@
+package emv
+
+// AUC holds the Application Usage Control (9F07).
+type AUC uint64
+
+const (
+	AucDomesticCash          AUC = 1 << 15
+	AucInternationalCash     AUC = 1 << 14
+	AucDomesticGoods         AUC = 1 << 13
+	AucInternationalGoods    AUC = 1 << 12
+	AucDomesticServices      AUC = 1 << 11
+	AucInternationalServices AUC = 1 << 10
+	AucAtm                   AUC = 1 << 9
+	AucNonAtm                AUC = 1 << 8
+
+	AucDomesticCashback      AUC = 1 << 7
+	AucInternationalCashback AUC = 1 << 6
+)
+
+var aucNames = []bitName{
+	{uint64(AucDomesticCash), "Valid for domestic cash transactions"},
+	{uint64(AucInternationalCash), "Valid for international cash transactions"},
+	{uint64(AucDomesticGoods), "Valid for domestic goods"},
+	{uint64(AucInternationalGoods), "Valid for international goods"},
+	{uint64(AucDomesticServices), "Valid for domestic services"},
+	{uint64(AucInternationalServices), "Valid for international services"},
+	{uint64(AucAtm), "Valid at ATMs"},
+	{uint64(AucNonAtm), "Valid at terminals other than ATMs"},
+	{uint64(AucDomesticCashback), "Domestic cashback allowed"},
+	{uint64(AucInternationalCashback), "International cashback allowed"},
+}
+
+func (a *AUC) Set(bits AUC) {
+	*a |= bits
+}
+
+// Has reports whether any of bits is set.
+func (a AUC) Has(bits AUC) bool {
+	return a&bits != 0
+}
+
+func (a AUC) Bytes() []byte {
+	return encodeBits(uint64(a), 2)
+}
+
+func (a AUC) EncodeTlv() ([]byte, error) {
+	return a.Bytes(), nil
+}
+
+func (a *AUC) DecodeTlv(data []byte) error {
+	value, err := decodeBits(data, 2)
+
+	if err != nil {
+		return err
+	}
+
+	*a = AUC(value)
+
+	return nil
+}
+
+func (a AUC) String() string {
+	return describeBits(uint64(a), aucNames)
+}
@@ -0,0 +1,92 @@
+package emv
+
+import (
+	"fmt"
+	"github.com/greenboxal/emv-kernel/tlv"
+	"strings"
+)
+
+func init() {
+	tlv.RegisterDescriber(0x82, bitmapDescriber(func() bitmap { return new(AIP) }))
+	tlv.RegisterDescriber(0x95, bitmapDescriber(func() bitmap { return new(TVR) }))
+	tlv.RegisterDescriber(0x9B, bitmapDescriber(func() bitmap { return new(TSI) }))
+	tlv.RegisterDescriber(0x9F07, bitmapDescriber(func() bitmap { return new(AUC) }))
+	tlv.RegisterDescriber(0x9F33, bitmapDescriber(func() bitmap { return new(TerminalCapabilities) }))
+	tlv.RegisterDescriber(0x9F34, bitmapDescriber(func() bitmap { return new(CVMResults) }))
+	tlv.RegisterDescriber(0x9F40, bitmapDescriber(func() bitmap { return new(AdditionalTerminalCapabilities) }))
+}
+
+type bitmap interface {
+	tlv.TlvDecoder
+	fmt.Stringer
+}
+
+// bitmapDescriber describes values in tlv dumps with their bitmap type.
+func bitmapDescriber(newBitmap func() bitmap) func(value []byte) string {
+	return func(value []byte) string {
+		b := newBitmap()
+
+		err := b.DecodeTlv(value)
+
+		if err != nil {
+			return err.Error()
+		}
+
+		return b.String()
+	}
+}
+
+// bitName names a bit (or a group of bits) of an EMV bitmap.
+type bitName struct {
+	bits uint64
+	name string
+}
+
+// describeBits lists the names of the bits set in value.
+func describeBits(value uint64, names []bitName) string {
+	set := make([]string, 0)
+
+	for _, n := range names {
+		if value&n.bits == n.bits {
+			set = append(set, n.name)
+			value &^= n.bits
+		}
+	}
+
+	if value != 0 {
+		set = append(set, fmt.Sprintf("Unknown bits %X", value))
+	}
+
+	if len(set) == 0 {
+		return "None"
+	}
+
+	return strings.Join(set, ", ")
+}
+
+// encodeBits encodes value as a big endian bitmap of length bytes.
+func encodeBits(value uint64, length int) []byte {
+	data := make([]byte, length)
+
+	for i := length - 1; i >= 0; i-- {
+		data[i] = byte(value)
+		value >>= 8
+	}
+
+	return data
+}
+
+// decodeBits decodes a big endian bitmap that must have length bytes.
+func decodeBits(data []byte, length int) (uint64, error) {
+	if len(data) != length {
+		return 0, fmt.Errorf("invalid bitmap length %d, expected %d", len(data), length)
+	}
+
+	value := uint64(0)
+
+	for _, b := range data {
+		value = value<<8 | uint64(b)
+	}
+
+	return value, nil
+}
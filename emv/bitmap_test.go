@@ -0,0 +1,31 @@
+package emv
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTvr(t *testing.T) {
+	tvr := TVR(0)
+	tvr.Set(TvrOfflineNotPerformed)
+	tvr.Set(TvrExpiredApplication)
+
+	assert.True(t, tvr.Has(TvrExpiredApplication))
+	assert.False(t, tvr.Has(TvrCvmFailed))
+	assert.Equal(t, []byte{0x80, 0x40, 0x00, 0x00, 0x00}, tvr.Bytes())
+	assert.Equal(t, "Offline data authentication not performed, Expired application", tvr.String())
+
+	var decoded TVR
+	assert.Nil(t, decoded.DecodeTlv([]byte{0x00, 0x00, 0x80, 0x00, 0x40}))
+	assert.Equal(t, TvrCvmFailed|TvrIssuerAuthFailed, decoded)
+	assert.NotNil(t, decoded.DecodeTlv([]byte{0x00}))
+}
+
+func TestBitmapStrings(t *testing.T) {
+	assert.Equal(t, "None", TSI(0).String())
+	assert.Equal(t, "SDA supported, Cardholder verification is supported", (AipSdaSupported | AipCvmSupported).String())
+	assert.Equal(t, "Valid at ATMs, Unknown bits 1", (AucAtm | 1).String())
+	assert.Equal(t, []byte{0x20, 0xB8, 0xC8}, TerminalCapabilities(0x20B8C8).Bytes())
+	assert.Equal(t, "Cash, Numeric keys", (AddCapCash | AddCapNumericKeys).String())
+	assert.Equal(t, "Plaintext PIN verified by ICC, Always, Successful", NewCVMResults(0x41, 0x00, CvmResultSuccessful).String())
+}
@@ -3,16 +3,24 @@ package emv
 import (
 	"encoding/hex"
 	"fmt"
-	"github.com/ebfe/scard"
 	"github.com/greenboxal/emv-kernel/tlv"
+	"io"
 )
 
 type Card struct {
-	*scard.Card
+	transport Transport
 }
 
-func NewCard(card *scard.Card) *Card {
-	return &Card{card}
+func NewCard(transport Transport) *Card {
+	return &Card{transport}
+}
+
+func (c *Card) Reset() ([]byte, error) {
+	return c.transport.Reset()
+}
+
+func (c *Card) Close() error {
+	return c.transport.Close()
 }
 
 func (c *Card) SendRawApdu(apdu *Apdu) (*ApduResponse, error) {
@@ -41,7 +49,7 @@ func (c *Card) SendRawApdu(apdu *Apdu) (*ApduResponse, error) {
 
 	fmt.Printf("SENT %s\n", hex.EncodeToString(req))
 
-	res, err := c.Transmit(req)
+	res, err := c.transport.Transmit(req)
 
 	if err != nil {
 		return nil, err
@@ -49,6 +57,10 @@ func (c *Card) SendRawApdu(apdu *Apdu) (*ApduResponse, error) {
 
 	fmt.Printf("RECV %s\n", hex.EncodeToString(res))
 
+	if len(res) < 2 {
+		return nil, fmt.Errorf("invalid response")
+	}
+
 	return &ApduResponse{
 		res[:len(res)-2],
 		res[len(res)-2],
@@ -150,19 +162,15 @@ func (c *Card) SelectApplication(name []byte, first bool) (*Application, bool, e
 	return app, true, nil
 }
 
-func (c *Card) GetProcessingOptions(pdol tlv.Tlv) (*ProcessingOptions, error) {
-	pdolData, err := pdol.EncodeTlv()
-
-	if err != nil {
-		return nil, err
-	}
-
+// GetProcessingOptions initiates the transaction, sending the PDOL related
+// data in the Command Template (83).
+func (c *Card) GetProcessingOptions(pdolData []byte) (*ProcessingOptions, error) {
 	res, err := c.SendApdu(&Apdu{
 		Class:       0x80,
 		Instruction: 0xA8,
 		P1:          0x00,
 		P2:          0x00,
-		Data:        pdolData,
+		Data:        tlv.Node{Tag: 0x83, Value: pdolData}.Encode(),
 		Expected:    0,
 	})
 
@@ -195,26 +203,140 @@ func (c *Card) GetProcessingOptions(pdol tlv.Tlv) (*ProcessingOptions, error) {
 			return nil, fmt.Errorf("Invalid message")
 		}
 
-		aip, err := tlv.DecodeInteger(raw[0:2])
+		if len(raw) < 2 {
+			return nil, &tlv.DecodeError{Offset: len(raw), Path: []int{0x80}, Err: tlv.ErrTruncated}
+		}
+
+		err = po.ApplicationInterchangeProfile.DecodeTlv(raw[0:2])
 
 		if err != nil {
 			return nil, err
 		}
 
-		po.ApplicationInterchangeProfile = int(aip)
-		po.ApplicationFileList.DecodeTlv(raw[2:])
-	}
+		err = po.ApplicationFileList.DecodeTlv(raw[2:])
+
+		if err != nil {
+			return nil, tlv.NestError(tlv.ShiftError(err, 2), 0x80)
+		}
 
-	fmt.Printf("%#+v\n", po)
+		po.Raw = tlv.Tlv{0x82: raw[0:2], 0x94: raw[2:]}
+	}
 
 	return po, nil
 }
 
-func (c *Card) VerifyPin(pin string) (bool, error) {
+// VerifyPin verifies an offline plaintext PIN, returning the remaining PIN
+// tries when the card reports them or -1 otherwise.
+func (c *Card) VerifyPin(pin string) (bool, int, error) {
+	pinBlock, err := buildPinBlock(pin)
+
+	if err != nil {
+		return false, -1, err
+	}
+
+	return c.verify(0x80, pinBlock)
+}
+
+// VerifyEncipheredPin verifies an offline PIN enciphered with the ICC PIN
+// encipherment key (or the ICC key) as defined in EMV Book 2 section 7.
+func (c *Card) VerifyEncipheredPin(pin string, key *PublicKey, random io.Reader) (bool, int, error) {
+	pinBlock, err := buildPinBlock(pin)
+
+	if err != nil {
+		return false, -1, err
+	}
+
+	challenge, err := c.GetChallenge()
+
+	if err != nil {
+		return false, -1, err
+	}
+
+	size := len(key.Modulus())
+
+	if size < 9+len(challenge) {
+		return false, -1, fmt.Errorf("invalid pin encipherment key")
+	}
+
+	data := make([]byte, 0, size)
+	data = append(data, 0x7F)
+	data = append(data, pinBlock...)
+	data = append(data, challenge...)
+
+	padding := make([]byte, size-len(data))
+
+	_, err = io.ReadFull(random, padding)
+
+	if err != nil {
+		return false, -1, err
+	}
+
+	data = append(data, padding...)
+
+	enciphered, err := key.Encrypt(data)
+
+	if err != nil {
+		return false, -1, err
+	}
+
+	return c.verify(0x88, enciphered)
+}
+
+func (c *Card) GetChallenge() ([]byte, error) {
+	res, err := c.SendApdu(&Apdu{
+		Class:       0x00,
+		Instruction: 0x84,
+		P1:          0x00,
+		P2:          0x00,
+		Data:        nil,
+		Expected:    0,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.SW1 != 0x90 || res.SW2 != 0x00 || len(res.Body) != 8 {
+		return nil, fmt.Errorf("an error ocurred processing command")
+	}
+
+	return res.Body, nil
+}
+
+func (c *Card) verify(qualifier byte, data []byte) (bool, int, error) {
+	res, err := c.SendApdu(&Apdu{
+		Class:       0x00,
+		Instruction: 0x20,
+		P1:          0x00,
+		P2:          qualifier,
+		Data:        data,
+		Expected:    0,
+	})
+
+	if err != nil {
+		return false, -1, err
+	}
+
+	if res.SW1 == 0x90 && res.SW2 == 0x00 {
+		return true, -1, nil
+	}
+
+	if res.SW1 == 0x63 && res.SW2&0xF0 == 0xC0 {
+		return false, int(res.SW2 & 0x0F), nil
+	}
+
+	if res.SW1 == 0x69 && (res.SW2 == 0x83 || res.SW2 == 0x84) {
+		return false, 0, nil
+	}
+
+	return false, -1, nil
+}
+
+func buildPinBlock(pin string) ([]byte, error) {
 	pinBlock := make([]byte, 8)
 
 	if len(pin) < 4 || len(pin) > 12 {
-		return false, fmt.Errorf("wrong pin size")
+		return nil, fmt.Errorf("wrong pin size")
 	}
 
 	pinBlock[0] = byte((1 << 5) | len(pin))
@@ -237,12 +359,49 @@ func (c *Card) VerifyPin(pin string) (bool, error) {
 
 	pinBlock[7] = 0xFF
 
+	return pinBlock, nil
+}
+
+// GetData retrieves a data object not available in the application records,
+// returning nil if the card doesn't have it.
+func (c *Card) GetData(tag int) ([]byte, error) {
+	res, err := c.SendApdu(&Apdu{
+		Class:       0x80,
+		Instruction: 0xCA,
+		P1:          byte(tag >> 8),
+		P2:          byte(tag),
+		Data:        nil,
+		Expected:    0,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.SW1 != 0x90 || res.SW2 != 0x00 {
+		return nil, nil
+	}
+
+	body, err := tlv.DecodeTlv(res.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	value, _, err := body.Bytes(tag)
+
+	return value, err
+}
+
+// ExternalAuthenticate sends the Issuer Authentication Data to the card,
+// returning whether the card accepted it.
+func (c *Card) ExternalAuthenticate(data []byte) (bool, error) {
 	res, err := c.SendApdu(&Apdu{
 		Class:       0x00,
-		Instruction: 0x20,
+		Instruction: 0x82,
 		P1:          0x00,
-		P2:          1 << 7,
-		Data:        pinBlock,
+		P2:          0x00,
+		Data:        data,
 		Expected:    0,
 	})
 
@@ -253,13 +412,64 @@ func (c *Card) VerifyPin(pin string) (bool, error) {
 	return res.SW1 == 0x90 && res.SW2 == 0x00, nil
 }
 
-func (c *Card) GenerateAC(kind int, dol tlv.Tlv) (*GeneratedAC, error) {
-	data, err := dol.EncodeTlv()
+func (c *Card) InternalAuthenticate(data []byte) ([]byte, error) {
+	res, err := c.SendApdu(&Apdu{
+		Class:       0x00,
+		Instruction: 0x88,
+		P1:          0x00,
+		P2:          0x00,
+		Data:        data,
+		Expected:    0,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.SW1 != 0x90 || res.SW2 != 0x00 {
+		return nil, fmt.Errorf("an error ocurred processing command")
+	}
+
+	body, err := tlv.DecodeTlv(res.Body)
 
 	if err != nil {
 		return nil, err
 	}
 
+	sdad, found, err := body.Bytes(0x80)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if found {
+		return sdad, nil
+	}
+
+	template, found, err := body.Tlv(0x77)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, fmt.Errorf("invalid message")
+	}
+
+	sdad, found, err = template.Bytes(0x9F4B)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, fmt.Errorf("invalid message")
+	}
+
+	return sdad, nil
+}
+
+func (c *Card) GenerateAC(kind int, data []byte) (*GeneratedAC, error) {
 	res, err := c.SendApdu(&Apdu{
 		Class:       0x80,
 		Instruction: 0xAE,
@@ -269,7 +479,11 @@ func (c *Card) GenerateAC(kind int, dol tlv.Tlv) (*GeneratedAC, error) {
 		Expected:    0,
 	})
 
-	if res.SW1 != 0x90 && res.SW2 != 0x00 {
+	if err != nil {
+		return nil, err
+	}
+
+	if res.SW1 != 0x90 || res.SW2 != 0x00 {
 		return nil, fmt.Errorf("an error ocurred processing command")
 	}
 
@@ -279,9 +493,33 @@ func (c *Card) GenerateAC(kind int, dol tlv.Tlv) (*GeneratedAC, error) {
 		return nil, err
 	}
 
-	ac := &GeneratedAC{}
+	raw, found, err := body.Bytes(0x80)
 
-	found, err := body.UnmarshalValue(0x77, ac)
+	if err != nil {
+		return nil, err
+	}
+
+	if found {
+		if len(raw) < 11 {
+			return nil, fmt.Errorf("invalid message")
+		}
+
+		atc, err := tlv.DecodeUInt(raw[1:3])
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &GeneratedAC{
+			CryptogramInformationData:     uint(raw[0]),
+			ApplicationTransactionCounter: uint(atc),
+			Cryptogram:                    raw[3:11],
+			IssuerApplicationData:         raw[11:],
+			Raw:                           body,
+		}, nil
+	}
+
+	template, found, err := body.Bytes(0x77)
 
 	if err != nil {
 		return nil, err
@@ -291,5 +529,19 @@ func (c *Card) GenerateAC(kind int, dol tlv.Tlv) (*GeneratedAC, error) {
 		return nil, fmt.Errorf("an error ocurred processing command")
 	}
 
+	values, err := tlv.DecodeTlv(template)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ac := &GeneratedAC{template: template}
+
+	err = values.Unmarshal(ac)
+
+	if err != nil {
+		return nil, err
+	}
+
 	return ac, nil
 }
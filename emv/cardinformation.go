@@ -1,15 +1,34 @@
 package emv
 
-import "github.com/greenboxal/emv-kernel/tlv"
+import (
+	"github.com/greenboxal/emv-kernel/tlv"
+	"time"
+)
 
 type CardInformation struct {
-	Pan            string `tlv:"5A,hex"`
-	SequenceNumber int    `tlv:"5F34"`
-	ExpiracyDate   string `tlv:"5F24,hex"`
-	HolderName     string `tlv:"5F20"`
-	Track2         string `tlv:"57,hex"`
+	Pan            string    `tlv:"5A,cn"`
+	SequenceNumber int       `tlv:"5F34,n"`
+	ExpiracyDate   time.Time `tlv:"5F24"`
+	EffectiveDate  time.Time `tlv:"5F25"`
+	HolderName     string    `tlv:"5F20"`
+	Track2         string    `tlv:"57,hex"`
 
-	RiskManagementData DataObjectList `tlv:"8C"`
+	ApplicationVersion      []byte `tlv:"9F08"`
+	ApplicationUsageControl AUC    `tlv:"9F07"`
+	IssuerCountryCode       int    `tlv:"5F28,n"`
+
+	CvmList                 CvmList `tlv:"8E"`
+	ApplicationCurrencyCode int     `tlv:"9F42,n"`
+
+	RiskManagementData       DataObjectList `tlv:"8C"`
+	IssuerRiskManagementData DataObjectList `tlv:"8D"`
+
+	LowerConsecutiveOfflineLimit []byte `tlv:"9F14"`
+	UpperConsecutiveOfflineLimit []byte `tlv:"9F23"`
+
+	IssuerActionCodeDefault []byte `tlv:"9F0D"`
+	IssuerActionCodeDenial  []byte `tlv:"9F0E"`
+	IssuerActionCodeOnline  []byte `tlv:"9F0F"`
 
 	SchemePublicKeyIndex int `tlv:"8F"`
 
@@ -19,6 +38,14 @@ type CardInformation struct {
 
 	IccPublicKeyCertificate []byte `tlv:"9F46"`
 	IccPublicKeyRemainder   []byte `tlv:"9F48"`
+	IccPublicKeyExponent    []byte `tlv:"9F47"`
+
+	IccPinEnciphermentPublicKeyCertificate []byte `tlv:"9F2D"`
+	IccPinEnciphermentPublicKeyExponent    []byte `tlv:"9F2E"`
+	IccPinEnciphermentPublicKeyRemainder   []byte `tlv:"9F2F"`
+
+	Ddol DataObjectList `tlv:"9F49"`
+	Tdol DataObjectList `tlv:"97"`
 
 	SignedStaticApplicationData []byte  `tlv:"93"`
 	SdaTags                     TagList `tlv:"9F4A"`
@@ -4,40 +4,76 @@ import (
 	"bytes"
 	"crypto/rand"
 	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
-	"github.com/ebfe/scard"
 	"github.com/greenboxal/emv-kernel/tlv"
+	"io"
 	"math/big"
+	"strings"
+	"time"
 )
 
 type Context struct {
 	card   *Card
 	config *ContextConfig
 	cm     CertificateManager
+	random io.Reader
 
 	Application       *Application
 	ProcessingOptions *ProcessingOptions
 	CardInformation   *CardInformation
 
-	tvr uint64
-	cvr uint64
+	// Data objects read from the application records, in the card order
+	RecordData tlv.List
+
+	// Record data objects not matching the dictionary, which cards often
+	// have and so don't stop the transaction
+	validationErrors []error
+
+	tvr TVR
+	tsi TSI
+	cvr CVMResults
 
 	sdaData                []byte
 	dataAuthenticationCode []byte
+	iccDynamicNumber       []byte
+	iccPublicKey           *PublicKey
+	unpredictableNumber    []byte
+
+	pdolData []byte
+	cdolData []byte
+
+	cryptogram                *GeneratedAC
+	authorisationResponseCode []byte
+	issuerAuthenticationData  []byte
+	issuerScriptResults       []byte
+
+	signatureRequired bool
+	onlinePin         string
+	language          string
 }
 
 func NewContext(card *Card, config *ContextConfig, cm CertificateManager) *Context {
+	random := config.Random
+
+	if random == nil {
+		random = rand.Reader
+	}
+
 	return &Context{
 		config:          config,
 		card:            card,
 		cm:              cm,
+		random:          random,
 		CardInformation: &CardInformation{},
 		sdaData:         []byte{},
 	}
 }
 
 func (c *Context) Initialize() error {
-	return c.card.Reconnect(scard.ShareExclusive, scard.ProtocolAny, scard.ResetCard)
+	_, err := c.card.Reset()
+
+	return err
 }
 
 func (c *Context) ListApplications(contactless bool, hints []ApplicationHint) ([]*ApplicationInformation, error) {
@@ -70,34 +106,43 @@ func (c *Context) ListApplications(contactless bool, hints []ApplicationHint) ([
 				break
 			}
 
-			tlv, err := tlv.DecodeTlv(res.Body)
+			body, err := tlv.DecodeList(res.Body)
 
 			if err != nil {
 				return nil, err
 			}
 
-			tlv, found, err = tlv.Tlv(0x70)
-
-			if err != nil {
-				return nil, err
-			}
+			template, found := body.Find(0x70)
 
 			if !found {
 				return nil, fmt.Errorf("invalid PSE record")
 			}
 
-			info := &ApplicationInformation{}
-			found, err := tlv.UnmarshalValue(0x61, info)
+			entries, err := tlv.DecodeList(template)
 
 			if err != nil {
 				return nil, err
 			}
 
-			if !found {
-				return nil, fmt.Errorf("invalid PSE record")
+			// A record can hold several application entries
+			for _, entry := range entries.FindAll(0x61) {
+				values, err := tlv.DecodeTlv(entry)
+
+				if err != nil {
+					return nil, err
+				}
+
+				info := &ApplicationInformation{}
+
+				err = values.Unmarshal(info)
+
+				if err != nil {
+					return nil, err
+				}
+
+				result = append(result, info)
 			}
 
-			result = append(result, info)
 			record++
 		}
 	} else {
@@ -145,7 +190,7 @@ func (c *Context) ListApplications(contactless bool, hints []ApplicationHint) ([
 }
 
 func (c *Context) SelectApplication(applicationName []byte) (*Application, error) {
-	var pdol tlv.Tlv
+	var pdolData []byte
 
 	app, found, err := c.card.SelectApplication(applicationName, true)
 
@@ -158,17 +203,16 @@ func (c *Context) SelectApplication(applicationName []byte) (*Application, error
 	}
 
 	if app.Template.ProcessingObjects != nil {
-		pdol, err = c.buildDol(app.Template.ProcessingObjects, nil)
+		pdolData, err = c.buildDol(app.Template.ProcessingObjects, nil)
 
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		pdol = make(tlv.Tlv)
-		pdol.MarshalValue(0x83, []byte{})
 	}
 
-	opts, err := c.card.GetProcessingOptions(pdol)
+	c.pdolData = pdolData
+
+	opts, err := c.card.GetProcessingOptions(pdolData)
 
 	if err != nil {
 		return nil, err
@@ -176,6 +220,7 @@ func (c *Context) SelectApplication(applicationName []byte) (*Application, error
 
 	c.Application = app
 	c.ProcessingOptions = opts
+	c.language = c.config.Terminal.selectLanguage(app.Template.LanguagePreference)
 
 	for _, app := range opts.ApplicationFileList {
 		sdaCount := app.SdaCount
@@ -187,17 +232,13 @@ func (c *Context) SelectApplication(applicationName []byte) (*Application, error
 				return nil, err
 			}
 
-			body, err := tlv.DecodeTlv(record.Body)
+			body, err := tlv.DecodeList(record.Body)
 
 			if err != nil {
 				return nil, err
 			}
 
-			templateBytes, found, err := body.Bytes(0x70)
-
-			if err != nil {
-				return nil, err
-			}
+			templateBytes, found := body.Find(0x70)
 
 			if !found {
 				return nil, fmt.Errorf("malformed application file")
@@ -214,12 +255,23 @@ func (c *Context) SelectApplication(applicationName []byte) (*Application, error
 				sdaCount--
 			}
 
-			template, err := tlv.DecodeTlv(templateBytes)
+			objects, err := tlv.DecodeList(templateBytes)
 
 			if err != nil {
 				return nil, err
 			}
 
+			c.RecordData = append(c.RecordData, objects...)
+			template := objects.Map()
+
+			for _, n := range objects {
+				if e, found := tlv.Lookup(n.Tag); found {
+					if err := e.Validate(n.Value); err != nil {
+						c.validationErrors = append(c.validationErrors, err)
+					}
+				}
+			}
+
 			err = template.Unmarshal(c.CardInformation)
 
 			if err != nil {
@@ -233,8 +285,22 @@ func (c *Context) SelectApplication(applicationName []byte) (*Application, error
 
 func (c *Context) Authenticate() (bool, error) {
 	success := true
+	aip := c.ProcessingOptions.ApplicationInterchangeProfile
+	capabilities := c.config.Terminal.Capabilities
+
+	if aip&AipCdaSupported != 0 && capabilities&CapCda != 0 {
+		ok, err := c.prepareCda()
+
+		if err != nil {
+			return false, err
+		}
+
+		if !ok {
+			c.tvr |= TvrCdaFailed
+		}
 
-	if c.ProcessingOptions.ApplicationInterchangeProfile&AipDdaSupported != 0 {
+		success = success && ok
+	} else if aip&AipDdaSupported != 0 && capabilities&CapDda != 0 {
 		ok, err := c.authenticateDda()
 
 		if err != nil {
@@ -246,7 +312,7 @@ func (c *Context) Authenticate() (bool, error) {
 		}
 
 		success = success && ok
-	} else if c.ProcessingOptions.ApplicationInterchangeProfile&AipSdaSupported != 0 {
+	} else if aip&AipSdaSupported != 0 && capabilities&CapSda != 0 {
 		ok, err := c.authenticateSda()
 
 		if err != nil {
@@ -262,212 +328,1357 @@ func (c *Context) Authenticate() (bool, error) {
 		c.tvr |= TvrOfflineNotPerformed
 	}
 
-	return true, nil
+	if c.tvr&TvrOfflineNotPerformed == 0 {
+		c.tsi |= TsiOdaPerformed
+	}
+
+	return success, nil
 }
 
-func (c *Context) VerifyCardholder(pinAsker PinAsker) (bool, error) {
-	pin, err := pinAsker.RetrievePin()
+// ProcessRestrictions checks the application version, usage control and
+// dates against the transaction, setting the matching TVR bits.
+func (c *Context) ProcessRestrictions(tx *Transaction) error {
+	info := c.CardInformation
+	terminalVersion := c.applicationConfig().Version
 
-	if err != nil {
-		return false, err
+	if info.ApplicationVersion != nil && terminalVersion != nil && !bytes.Equal(info.ApplicationVersion, terminalVersion) {
+		c.tvr |= TvrDifferentVersions
 	}
 
-	ok, err := c.card.VerifyPin(pin)
+	if _, found := info.Raw[0x9F07]; found && !c.usageAllowed(tx) {
+		c.tvr |= TvrNotProductAllowed
+	}
 
-	if err != nil {
-		return false, err
+	// Without a transaction date the application dates can't be checked
+	if tx.Date.IsZero() {
+		return nil
 	}
 
-	if !ok {
-		c.tvr |= TvrCvmFailed
+	if !info.EffectiveDate.IsZero() && dateValue(tx.Date) < dateValue(info.EffectiveDate) {
+		c.tvr |= TvrNotYetEffective
 	}
 
-	return ok, nil
+	if !info.ExpiracyDate.IsZero() && dateValue(tx.Date) > dateValue(info.ExpiracyDate) {
+		c.tvr |= TvrExpiredApplication
+	}
+
+	return nil
 }
 
-func (c *Context) GenerateCryptogram(tx *Transaction) (*TransactionResult, error) {
-	return nil, nil
-}
-
-func (c *Context) buildDol(dol DataObjectList, tx *Transaction) (tlv.Tlv, error) {
-	t := make(tlv.Tlv)
-
-	for tag, length := range dol {
-		switch tag {
-		case 0x9F02:
-			t.MarshalValue(tag, tx.Amount)
-		case 0x9F03:
-			t.MarshalValue(tag, tx.AdditionalAmount)
-		case 0x9F1A:
-			t.MarshalValue(tag, c.config.Terminal.CountryCode)
-		case 0x95:
-			t.MarshalValue(tag, c.tvr)
-		case 0x5F2A:
-			t.MarshalValue(tag, c.config.Terminal.CurrencyCode)
-		case 0x9A:
-			t.MarshalValue(tag, tx.Date)
-		case 0x9C:
-			t.MarshalValue(tag, tx.Type)
-		case 0x9F37:
-			number, err := c.generateUnpredictableNumber(length)
+func (c *Context) usageAllowed(tx *Transaction) bool {
+	info := c.CardInformation
+	auc := info.ApplicationUsageControl
 
-			if err != nil {
-				return nil, err
-			}
+	if c.config.Terminal.isAtm() {
+		if auc&AucAtm == 0 {
+			return false
+		}
+	} else if auc&AucNonAtm == 0 {
+		return false
+	}
+
+	if _, found := info.Raw[0x5F28]; !found {
+		return true
+	}
 
-			t.MarshalValue(tag, number)
-		case 0x9F35:
-			t.MarshalValue(tag, c.config.Terminal.Type)
-		case 0x9F45:
-			t.MarshalValue(tag, c.dataAuthenticationCode)
-		case 0x9F34:
-			t.MarshalValue(tag, c.cvr)
-		default:
-			t, found := tlv.Pick(tag, c.CardInformation.Raw, c.ProcessingOptions.Raw)
+	domestic := info.IssuerCountryCode == c.config.Terminal.CountryCode
 
-			if found {
-				value, _, _ := t.Bytes(tag)
+	switch tx.Type {
+	case TransactionTypeCash:
+		if domestic {
+			return auc&AucDomesticCash != 0
+		}
+
+		return auc&AucInternationalCash != 0
+	case TransactionTypePurchase, TransactionTypeCashback:
+		if domestic && auc&(AucDomesticGoods|AucDomesticServices) == 0 {
+			return false
+		}
+
+		if !domestic && auc&(AucInternationalGoods|AucInternationalServices) == 0 {
+			return false
+		}
 
-				t.MarshalValue(tag, value)
+		if tx.Type == TransactionTypeCashback || tx.AdditionalAmount != 0 {
+			if domestic {
+				return auc&AucDomesticCashback != 0
 			}
+
+			return auc&AucInternationalCashback != 0
 		}
 	}
 
-	return t, nil
+	return true
 }
 
-func (c *Context) authenticateSda() (bool, error) {
-	pub, err := c.retrieveIssuerPublicKey()
+// dateValue returns the calendar day of date as an YYYYMMDD integer, so dates
+// compare regardless of their time and location.
+func dateValue(date time.Time) int {
+	return date.Year()*10000 + int(date.Month())*100 + date.Day()
+}
 
-	if err != nil {
-		return false, err
+// VerifyCardholder processes the card CVM List, performing the first
+// cardholder verification method that applies to the transaction.
+func (c *Context) VerifyCardholder(tx *Transaction, pinAsker PinAsker) (bool, error) {
+	if c.ProcessingOptions.ApplicationInterchangeProfile&AipCvmSupported == 0 {
+		c.cvr = NewCVMResults(CvmNotPerformed, 0, CvmResultUnknown)
+		return false, nil
 	}
 
-	sad, err := pub.Decrypt(c.CardInformation.SignedStaticApplicationData)
+	c.tsi |= TsiCvmPerformed
 
-	if err != nil {
-		return false, err
+	list := c.CardInformation.CvmList
+
+	if len(list.Rules) == 0 {
+		c.tvr |= TvrIccDataMissing
+		c.cvr = NewCVMResults(CvmNotPerformed, 0, CvmResultUnknown)
+		return false, nil
 	}
 
-	if sad[len(sad)-1] != 0xBC {
-		return false, fmt.Errorf("invalid static application data")
+	var failed *CvmRule
+
+	for i, rule := range list.Rules {
+		if !c.cvmConditionSatisfied(rule, tx) {
+			continue
+		}
+
+		result, err := c.performCvm(rule, pinAsker)
+
+		if err != nil {
+			return false, err
+		}
+
+		if result != CvmResultFailed {
+			c.cvr = NewCVMResults(rule.code(), rule.Condition, result)
+			return true, nil
+		}
+
+		failed = &list.Rules[i]
+
+		if !rule.ApplySucceeding {
+			break
+		}
 	}
 
-	if sad[0] != 0x6A {
-		return false, fmt.Errorf("invalid static application data")
+	c.tvr |= TvrCvmFailed
+
+	if failed != nil {
+		c.cvr = NewCVMResults(failed.code(), failed.Condition, CvmResultFailed)
+	} else {
+		c.cvr = NewCVMResults(CvmNotPerformed, 0, CvmResultFailed)
 	}
 
-	if sad[1] != 0x03 {
-		return false, fmt.Errorf("invalid static application data")
+	return false, nil
+}
+
+// ValidationErrors returns the record data objects found not to match their
+// length or format in the data element dictionary.
+func (c *Context) ValidationErrors() []error {
+	return c.validationErrors
+}
+
+// Language returns the language to use with the cardholder, selected from the
+// card Language Preference and the terminal languages.
+func (c *Context) Language() string {
+	return c.language
+}
+
+// OnlinePin returns the PIN captured for online verification, if any.
+func (c *Context) OnlinePin() string {
+	return c.onlinePin
+}
+
+func (c *Context) cvmConditionSatisfied(rule CvmRule, tx *Transaction) bool {
+	list := c.CardInformation.CvmList
+	terminal := &c.config.Terminal
+	cash := tx.Type == TransactionTypeCash
+
+	switch rule.Condition {
+	case CvmConditionAlways:
+		return true
+	case CvmConditionUnattendedCash:
+		return cash && terminal.isUnattended()
+	case CvmConditionNotCash:
+		return !cash && tx.Type != TransactionTypeCashback
+	case CvmConditionSupported:
+		return c.terminalSupportsCvm(rule.Method)
+	case CvmConditionManualCash:
+		return cash && !terminal.isUnattended()
+	case CvmConditionCashback:
+		return tx.Type == TransactionTypeCashback
+	case CvmConditionUnderX:
+		return c.amountInApplicationCurrency() && tx.Amount < list.X
+	case CvmConditionOverX:
+		return c.amountInApplicationCurrency() && tx.Amount > list.X
+	case CvmConditionUnderY:
+		return c.amountInApplicationCurrency() && tx.Amount < list.Y
+	case CvmConditionOverY:
+		return c.amountInApplicationCurrency() && tx.Amount > list.Y
 	}
 
-	sdaTags, err := c.buildSdaTags()
+	return false
+}
+
+func (c *Context) amountInApplicationCurrency() bool {
+	code := c.CardInformation.ApplicationCurrencyCode
+
+	return code != 0 && code == c.config.Terminal.CurrencyCode
+}
+
+func (c *Context) terminalSupportsCvm(method int) bool {
+	capabilities := c.config.Terminal.Capabilities
+
+	switch method {
+	case CvmFail:
+		return true
+	case CvmPlaintextPin:
+		return capabilities&CapPlaintextPin != 0
+	case CvmEncipheredOnlinePin:
+		return capabilities&CapEncipheredOnlinePin != 0
+	case CvmPlaintextPinAndSignature:
+		return capabilities&CapPlaintextPin != 0 && capabilities&CapSignature != 0
+	case CvmEncipheredPin:
+		return capabilities&CapEncipheredOfflinePin != 0
+	case CvmEncipheredPinAndSignature:
+		return capabilities&CapEncipheredOfflinePin != 0 && capabilities&CapSignature != 0
+	case CvmSignature:
+		return capabilities&CapSignature != 0
+	case CvmNoCvm:
+		return capabilities&CapNoCvm != 0
+	}
+
+	return false
+}
+
+func (c *Context) performCvm(rule CvmRule, pinAsker PinAsker) (int, error) {
+	switch rule.Method {
+	case CvmFail:
+		return CvmResultFailed, nil
+	case CvmPlaintextPin, CvmPlaintextPinAndSignature, CvmEncipheredPin, CvmEncipheredPinAndSignature:
+		if !c.terminalSupportsCvm(rule.Method) {
+			c.tvr |= TvrNoPinpad
+			return CvmResultFailed, nil
+		}
+
+		enciphered := rule.Method == CvmEncipheredPin || rule.Method == CvmEncipheredPinAndSignature
+		ok, err := c.verifyOfflinePin(pinAsker, enciphered)
+
+		if err != nil {
+			return CvmResultFailed, err
+		}
+
+		if !ok {
+			return CvmResultFailed, nil
+		}
+
+		if rule.Method == CvmPlaintextPinAndSignature || rule.Method == CvmEncipheredPinAndSignature {
+			c.signatureRequired = true
+			return CvmResultUnknown, nil
+		}
+
+		return CvmResultSuccessful, nil
+	case CvmEncipheredOnlinePin:
+		if !c.terminalSupportsCvm(rule.Method) {
+			c.tvr |= TvrNoPinpad
+			return CvmResultFailed, nil
+		}
+
+		pin, err := pinAsker.RetrievePin()
+
+		if err != nil {
+			return CvmResultFailed, err
+		}
+
+		if pin == "" {
+			c.tvr |= TvrPinNotEntered
+			return CvmResultFailed, nil
+		}
+
+		c.onlinePin = pin
+		c.tvr |= TvrOnlinePinEntered
+
+		return CvmResultUnknown, nil
+	case CvmSignature:
+		if !c.terminalSupportsCvm(rule.Method) {
+			return CvmResultFailed, nil
+		}
+
+		c.signatureRequired = true
+
+		return CvmResultUnknown, nil
+	case CvmNoCvm:
+		if !c.terminalSupportsCvm(rule.Method) {
+			return CvmResultFailed, nil
+		}
+
+		return CvmResultSuccessful, nil
+	}
+
+	c.tvr |= TvrUnrecognizedCvm
+
+	return CvmResultFailed, nil
+}
+
+func (c *Context) verifyOfflinePin(pinAsker PinAsker, enciphered bool) (bool, error) {
+	var key *PublicKey
+
+	if enciphered {
+		var err error
+
+		key, err = c.retrievePinEnciphermentKey()
+
+		if err != nil {
+			return false, nil
+		}
+	}
+
+	counter, err := c.card.GetData(0x9F17)
 
 	if err != nil {
 		return false, err
 	}
 
-	data := sad[1:][:len(sad)-22]
-	data = append(data, c.sdaData...)
-	data = append(data, sdaTags...)
+	if len(counter) == 1 && counter[0] == 0 {
+		c.tvr |= TvrPinTryLimit
+		return false, nil
+	}
 
-	actualHash := sha1.Sum(data)
-	expectedHash := sad[len(sad)-21:][:20]
+	pin, err := pinAsker.RetrievePin()
 
-	if !bytes.Equal(expectedHash, actualHash[:]) {
-		fmt.Printf("%x\n%x\n", actualHash, expectedHash)
-		return false, fmt.Errorf("sda hash doesn't match")
+	if err != nil {
+		return false, err
 	}
 
-	c.dataAuthenticationCode = sad[3:][:2]
+	if pin == "" {
+		c.tvr |= TvrPinNotEntered
+		return false, nil
+	}
 
-	return true, nil
+	var ok bool
+	var tries int
+
+	if enciphered {
+		ok, tries, err = c.card.VerifyEncipheredPin(pin, key, c.random)
+	} else {
+		ok, tries, err = c.card.VerifyPin(pin)
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	if !ok && tries == 0 {
+		c.tvr |= TvrPinTryLimit
+	}
+
+	return ok, nil
 }
 
-func (c *Context) authenticateDda() (bool, error) {
-	return false, fmt.Errorf("not implemented")
+// Tvr returns the Terminal Verification Results (95).
+func (c *Context) Tvr() TVR {
+	return c.tvr
 }
 
-func (c *Context) buildSdaTags() ([]byte, error) {
-	result := make([]byte, 0)
+// Tsi returns the Transaction Status Information (9B).
+func (c *Context) Tsi() TSI {
+	return c.tsi
+}
 
-	for _, tag := range c.CardInformation.SdaTags {
-		t, found := tlv.Pick(tag, c.CardInformation.Raw, c.ProcessingOptions.Raw)
+// CvmResults returns the Cardholder Verification Method Results (9F34).
+func (c *Context) CvmResults() CVMResults {
+	return c.cvr
+}
 
-		if !found {
-			return nil, fmt.Errorf("missing SDA tag")
+// ManageRisk performs the Terminal Risk Management: floor limit checking,
+// random transaction selection, exception file and velocity checking.
+func (c *Context) ManageRisk(tx *Transaction) error {
+	if c.ProcessingOptions.ApplicationInterchangeProfile&AipTerminalRiskManagement == 0 {
+		return nil
+	}
+
+	c.tsi |= TsiTerminalRiskManagement
+
+	config := c.applicationConfig()
+
+	if tx.Amount >= config.FloorLimit {
+		c.tvr |= TvrFloorLimit
+	}
+
+	if !c.config.Terminal.isOfflineOnly() && tx.Amount < config.FloorLimit {
+		selected, err := c.selectRandomly(tx, config)
+
+		if err != nil {
+			return err
 		}
 
-		value, _, err := t.Bytes(tag)
+		if selected {
+			c.tvr |= TvrRandomOnline
+		}
+	}
+
+	info := c.CardInformation
+
+	if c.config.ExceptionFile != nil {
+		blocked, err := c.config.ExceptionFile.Contains(info.Pan, info.SequenceNumber)
 
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		result = append(result, value...)
+		if blocked {
+			c.tvr |= TvrHotlist
+		}
 	}
 
-	return result, nil
+	if info.LowerConsecutiveOfflineLimit == nil || info.UpperConsecutiveOfflineLimit == nil {
+		return nil
+	}
+
+	return c.checkVelocity()
 }
 
-func (c *Context) retrieveIssuerPublicKey() (*PublicKey, error) {
-	rid := c.Application.DedicatedFileName[0:5]
-	index := c.CardInformation.SchemePublicKeyIndex
+// selectRandomly selects transactions for online processing.
+//
+// The probability goes from the target percentage at the threshold amount
+// up to the max target percentage at the floor limit.
+func (c *Context) selectRandomly(tx *Transaction, config *ApplicationConfig) (bool, error) {
+	n, err := rand.Int(c.random, big.NewInt(99))
 
-	pub, err := c.cm.GetSchemePublicKey(rid, index)
+	if err != nil {
+		return false, err
+	}
+
+	number := int(n.Int64()) + 1
+
+	if tx.Amount < config.Threshold {
+		return number <= config.TargetPercentage, nil
+	}
+
+	interpolated := (config.MaxTargetPercentage - config.TargetPercentage) * (tx.Amount - config.Threshold) / (config.FloorLimit - config.Threshold)
+
+	return number <= config.TargetPercentage+interpolated, nil
+}
+
+func (c *Context) checkVelocity() error {
+	info := c.CardInformation
+	lower, _ := tlv.DecodeUInt(info.LowerConsecutiveOfflineLimit)
+	upper, _ := tlv.DecodeUInt(info.UpperConsecutiveOfflineLimit)
+
+	atc, err := c.readCounter(0x9F36)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	cert, err := pub.Decrypt(c.CardInformation.IssuerPublicKeyCertificate)
+	lastOnline, err := c.readCounter(0x9F13)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	if cert[0] != 0x6A {
-		return nil, fmt.Errorf("invalid issuer public key")
+	if atc < 0 || lastOnline < 0 || atc <= lastOnline {
+		c.tvr |= TvrOfflineLowerLimit | TvrOfflineUpperLimit
+		return nil
 	}
 
-	if cert[1] != 0x02 {
-		return nil, fmt.Errorf("invalid issuer public key")
+	if uint64(atc-lastOnline) > lower {
+		c.tvr |= TvrOfflineLowerLimit
 	}
 
-	if cert[len(cert)-1] != 0xBC {
-		return nil, fmt.Errorf("invalid issuer public key")
+	if uint64(atc-lastOnline) > upper {
+		c.tvr |= TvrOfflineUpperLimit
 	}
 
-	schemeModulus := pub.Modulus()
+	if lastOnline == 0 {
+		c.tvr |= TvrNewCard
+	}
 
-	keycheck := cert[1:][:14+len(schemeModulus)-36]
-	keycheck = append(keycheck, c.CardInformation.IssuerPublicKeyRemainder...)
-	keycheck = append(keycheck, c.CardInformation.IssuerPublicKeyExponent...)
+	return nil
+}
 
-	expectedKeycheckHash := cert[15+len(schemeModulus)-36:][:20]
-	actualKeycheckHash := sha1.Sum(keycheck)
+// readCounter reads a counter with GET DATA, returning -1 if the card
+// doesn't have it.
+func (c *Context) readCounter(tag int) (int, error) {
+	data, err := c.card.GetData(tag)
 
-	if !bytes.Equal(expectedKeycheckHash, actualKeycheckHash[:]) {
-		fmt.Printf("%x\n%x\n", expectedKeycheckHash, actualKeycheckHash)
-		return nil, fmt.Errorf("hash doesn't match")
+	if err != nil {
+		return 0, err
+	}
+
+	if len(data) == 0 {
+		return -1, nil
+	}
+
+	value, err := tlv.DecodeUInt(data)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(value), nil
+}
+
+// TerminalActionAnalysis compares the TVR against the Terminal and Issuer
+// Action Codes to decide which cryptogram should be requested from the card.
+func (c *Context) TerminalActionAnalysis() CryptogramType {
+	tac := c.applicationConfig().Tac
+	info := c.CardInformation
+
+	denial := tac.Denial | issuerActionCode(info.IssuerActionCodeDenial, 0)
+
+	if c.tvr&denial != 0 {
+		return AacCryptogram
+	}
+
+	if c.config.Terminal.isOfflineOnly() {
+		return c.defaultAction()
+	}
+
+	online := tac.Online | issuerActionCode(info.IssuerActionCodeOnline, 0xFFFFFFFFFF)
+
+	if c.tvr&online != 0 {
+		return ArqcCryptogram
+	}
+
+	return TcCryptogram
+}
+
+// defaultAction decides the outcome of a transaction that can't go online.
+func (c *Context) defaultAction() CryptogramType {
+	tac := c.applicationConfig().Tac
+	def := tac.Default | issuerActionCode(c.CardInformation.IssuerActionCodeDefault, 0xFFFFFFFFFF)
+
+	if c.tvr&def != 0 {
+		return AacCryptogram
 	}
 
-	modulus := cert[15 : 15+len(schemeModulus)-36]
-	modulus = append(modulus, c.CardInformation.IssuerPublicKeyRemainder...)
+	return TcCryptogram
+}
+
+func (c *Context) applicationConfig() *ApplicationConfig {
+	for _, app := range c.config.Applications {
+		if c.Application != nil && bytes.HasPrefix(c.Application.DedicatedFileName, app.Aid) {
+			return app
+		}
+	}
+
+	return &ApplicationConfig{}
+}
+
+func issuerActionCode(iac []byte, missing TVR) TVR {
+	var value TVR
+
+	if value.DecodeTlv(iac) != nil {
+		return missing
+	}
+
+	return value
+}
+
+func (c *Context) GenerateCryptogram(tx *Transaction) (*TransactionResult, error) {
+	kind := cryptogramKind(c.TerminalActionAnalysis())
+
+	ac, err := c.generateAC(kind, c.CardInformation.RiskManagementData, tx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.tsi |= TsiCardRiskManagement
+
+	// An ARQC whose CDA signature failed can't go online, the card is left
+	// waiting for the second GENERATE AC so it is finished with an AAC. A TC
+	// already completed the transaction in the card.
+	if c.iccPublicKey != nil && c.tvr&TvrCdaFailed != 0 && ac.CryptogramType() == ArqcCryptogram {
+		c.authorisationResponseCode = []byte("Z1")
+
+		ac, err = c.generateAC(AcAac, c.CardInformation.IssuerRiskManagementData, tx)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.cryptogram = ac
+
+	switch ac.CryptogramType() {
+	case TcCryptogram:
+		c.authorisationResponseCode = []byte("Y1")
+	case AacCryptogram:
+		c.authorisationResponseCode = []byte("Z1")
+	}
+
+	return c.transactionResult(ac), nil
+}
+
+// CompleteTransaction issues the second GENERATE AC after online processing.
+//
+// arc is the Authorisation Response Code received from the issuer, or nil if
+// the terminal was unable to go online.
+func (c *Context) CompleteTransaction(tx *Transaction, arc []byte) (*TransactionResult, error) {
+	if c.cryptogram == nil || c.cryptogram.CryptogramType() != ArqcCryptogram {
+		return nil, fmt.Errorf("no online authorization pending")
+	}
+
+	kind := AcAac
+
+	if arc == nil {
+		if c.defaultAction() == TcCryptogram {
+			arc = []byte("Y3")
+			kind = AcTc
+		} else {
+			arc = []byte("Z3")
+		}
+	} else if isApprovalCode(arc) {
+		kind = AcTc
+	}
+
+	c.authorisationResponseCode = arc
+
+	ac, err := c.generateAC(kind, c.CardInformation.IssuerRiskManagementData, tx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ac.CryptogramType() == ArqcCryptogram {
+		return nil, fmt.Errorf("card requested online authorization twice")
+	}
+
+	c.cryptogram = ac
+
+	return c.transactionResult(ac), nil
+}
+
+// ProcessOnlineResponse authenticates the issuer and completes the transaction.
+//
+// A nil response means the terminal was unable to go online.
+func (c *Context) ProcessOnlineResponse(tx *Transaction, response *OnlineResponse) (*TransactionResult, error) {
+	if c.cryptogram == nil || c.cryptogram.CryptogramType() != ArqcCryptogram {
+		return nil, fmt.Errorf("no online authorization pending")
+	}
+
+	if response == nil {
+		return c.CompleteTransaction(tx, nil)
+	}
+
+	if len(response.AuthorisationResponseCode) != 2 {
+		return nil, fmt.Errorf("invalid authorisation response code")
+	}
+
+	err := c.authenticateIssuer(response.IssuerAuthenticationData)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.issuerScriptResults = nil
+
+	err = c.runIssuerScripts(IssuerScriptBeforeAC, response.IssuerScripts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.CompleteTransaction(tx, response.AuthorisationResponseCode)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.runIssuerScripts(IssuerScriptAfterAC, response.IssuerScripts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result.IssuerScriptResults = c.issuerScriptResults
+
+	return result, nil
+}
+
+// runIssuerScripts sends the commands of the scripts with the given
+// template to the card, stopping each script at its first failing command.
+func (c *Context) runIssuerScripts(template int, scripts []*IssuerScript) error {
+	for _, script := range scripts {
+		if script.Template != template {
+			continue
+		}
+
+		c.tsi |= TsiScriptProcessing
+
+		failed := 0
+
+		for i, command := range script.Commands {
+			ok, err := c.sendScriptCommand(command)
+
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				failed = i + 1
+				break
+			}
+		}
+
+		if failed > 0 {
+			if template == IssuerScriptBeforeAC {
+				c.tvr |= TvrScriptFailedBeforeAC
+			} else {
+				c.tvr |= TvrScriptFailedAfterAC
+			}
+		}
+
+		c.issuerScriptResults = append(c.issuerScriptResults, script.result(failed)...)
+	}
+
+	return nil
+}
+
+func (c *Context) sendScriptCommand(command []byte) (bool, error) {
+	apdu, err := parseApdu(command)
+
+	if err != nil {
+		return false, nil
+	}
+
+	res, err := c.card.SendApdu(apdu)
+
+	if err != nil {
+		return false, err
+	}
+
+	// Warnings (62xx and 63xx) don't stop the script
+	return res.SW1 == 0x90 || res.SW1 == 0x62 || res.SW1 == 0x63, nil
+}
+
+// IssuerScriptResults returns the Issuer Script Results (9F5B) of the
+// scripts processed so far.
+func (c *Context) IssuerScriptResults() []byte {
+	return c.issuerScriptResults
+}
+
+// authenticateIssuer sends the Issuer Authentication Data to the card, either
+// with EXTERNAL AUTHENTICATE or in the CDOL2 data when the card requests it.
+func (c *Context) authenticateIssuer(data []byte) error {
+	c.issuerAuthenticationData = nil
+
+	if len(data) == 0 || c.ProcessingOptions.ApplicationInterchangeProfile&AipIssuerAuthentication == 0 {
+		return nil
+	}
+
+	c.tsi |= TsiIssuerAuthentication
+
+	if c.CardInformation.IssuerRiskManagementData.Has(0x91) {
+		c.issuerAuthenticationData = data
+		return nil
+	}
+
+	ok, err := c.card.ExternalAuthenticate(data)
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		c.tvr |= TvrIssuerAuthFailed
+	}
+
+	return nil
+}
+
+func (c *Context) transactionResult(ac *GeneratedAC) *TransactionResult {
+	result := &TransactionResult{
+		SignatureRequired:             c.signatureRequired,
+		CryptogramType:                ac.CryptogramType(),
+		Cryptogram:                    ac.Cryptogram,
+		CryptogramInformationData:     ac.CryptogramInformationData,
+		ApplicationTransactionCounter: ac.ApplicationTransactionCounter,
+		IssuerApplicationData:         ac.IssuerApplicationData,
+	}
+
+	// A cryptogram whose CDA signature couldn't be verified is never trusted
+	if c.iccPublicKey != nil && c.tvr&TvrCdaFailed != 0 {
+		return result
+	}
+
+	switch result.CryptogramType {
+	case TcCryptogram:
+		result.Approved = true
+	case ArqcCryptogram:
+		result.ShouldGoOnline = true
+	}
+
+	return result
+}
+
+func cryptogramKind(kind CryptogramType) int {
+	switch kind {
+	case TcCryptogram:
+		return AcTc
+	case ArqcCryptogram:
+		return AcArqc
+	}
+
+	return AcAac
+}
+
+func isApprovalCode(arc []byte) bool {
+	switch string(arc) {
+	case "00", "10", "11":
+		return true
+	}
+
+	return false
+}
+
+// buildDol builds the DOL related data.
+//
+// Data elements are looked up in the transaction, the terminal configuration,
+// the kernel state and the card data, in that order.
+func (c *Context) buildDol(dol DataObjectList, tx *Transaction) ([]byte, error) {
+	sources := make([]DataSource, 0, 3)
+
+	if tx != nil {
+		sources = append(sources, tx)
+	}
+
+	if c.config.DataSource != nil {
+		sources = append(sources, c.config.DataSource)
+	}
+
+	sources = append(sources, &c.config.Terminal)
+
+	return dol.Encode(func(tag, length int) ([]byte, error) {
+		for _, source := range sources {
+			value, found, err := source.Data(tag)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if found {
+				return value, nil
+			}
+		}
+
+		if element, found := kernelElements[tag]; found {
+			return element(c, tx, length)
+		}
+
+		return c.cardData(tag), nil
+	})
+}
+
+// tcHashValue computes the TC Hash Value (98), the SHA-1 of the TDOL related
+// data, using the terminal default TDOL when the card doesn't have one.
+func (c *Context) tcHashValue(tx *Transaction) ([]byte, error) {
+	tdol := c.CardInformation.Tdol
+
+	if len(tdol) == 0 {
+		tdol = c.config.Terminal.DefaultTdol
+
+		if len(tdol) > 0 {
+			c.tvr |= TvrDefaultTdol
+		}
+	}
+
+	objects := make(DataObjectList, 0, len(tdol))
+
+	for _, object := range tdol {
+		if object.Tag != 0x98 {
+			objects = append(objects, object)
+		}
+	}
+
+	data, err := c.buildDol(objects, tx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha1.Sum(data)
+
+	return hash[:], nil
+}
+
+// cardData returns the value of tag read from the card, or nil.
+func (c *Context) cardData(tag int) []byte {
+	sources := []tlv.Tlv{c.CardInformation.Raw}
+
+	if c.ProcessingOptions != nil {
+		sources = append(sources, c.ProcessingOptions.Raw)
+	}
+
+	if t, found := tlv.Pick(tag, sources...); found {
+		return t[tag]
+	}
+
+	return nil
+}
+
+func (c *Context) authenticateSda() (bool, error) {
+	pub, err := c.retrieveIssuerPublicKey()
+
+	if err != nil {
+		return false, err
+	}
+
+	sad, err := pub.Decrypt(c.CardInformation.SignedStaticApplicationData)
+
+	if err != nil {
+		return false, err
+	}
+
+	if sad[len(sad)-1] != 0xBC {
+		return false, fmt.Errorf("invalid static application data")
+	}
+
+	if sad[0] != 0x6A {
+		return false, fmt.Errorf("invalid static application data")
+	}
+
+	if sad[1] != 0x03 {
+		return false, fmt.Errorf("invalid static application data")
+	}
+
+	sdaTags, err := c.buildSdaTags()
+
+	if err != nil {
+		return false, err
+	}
+
+	data := sad[1:][:len(sad)-22]
+	data = append(data, c.sdaData...)
+	data = append(data, sdaTags...)
+
+	actualHash := sha1.Sum(data)
+	expectedHash := sad[len(sad)-21:][:20]
+
+	if !bytes.Equal(expectedHash, actualHash[:]) {
+		return false, fmt.Errorf("sda hash doesn't match")
+	}
+
+	c.dataAuthenticationCode = sad[3:][:2]
+
+	return true, nil
+}
+
+func (c *Context) authenticateDda() (bool, error) {
+	info := c.CardInformation
+
+	if len(info.IssuerPublicKeyCertificate) == 0 || len(info.IccPublicKeyCertificate) == 0 || len(info.IccPublicKeyExponent) == 0 {
+		c.tvr |= TvrIccDataMissing
+		return false, nil
+	}
+
+	issuer, err := c.retrieveIssuerPublicKey()
+
+	if err != nil {
+		return false, nil
+	}
+
+	icc, err := c.retrieveIccPublicKey(issuer)
+
+	if err != nil {
+		return false, nil
+	}
+
+	ddol := info.Ddol
+
+	if len(ddol) == 0 {
+		ddol = c.config.Terminal.DefaultDdol
+
+		if !ddol.Has(0x9F37) {
+			return false, nil
+		}
+	}
+
+	ddolData, err := c.buildDol(ddol, nil)
+
+	if err != nil {
+		return false, err
+	}
+
+	signed, err := c.card.InternalAuthenticate(ddolData)
+
+	if err != nil {
+		return false, err
+	}
+
+	sdad, err := icc.Decrypt(signed)
+
+	if err != nil {
+		return false, err
+	}
+
+	if len(sdad) != len(icc.Modulus()) || len(sdad) < 25 {
+		return false, nil
+	}
+
+	if sdad[0] != 0x6A || sdad[1] != 0x05 || sdad[len(sdad)-1] != 0xBC {
+		return false, nil
+	}
+
+	data := append([]byte{}, sdad[1:len(sdad)-21]...)
+	data = append(data, ddolData...)
+
+	actualHash := sha1.Sum(data)
+	expectedHash := sdad[len(sdad)-21 : len(sdad)-1]
+
+	if !bytes.Equal(expectedHash, actualHash[:]) {
+		return false, nil
+	}
+
+	if int(sdad[3]) > len(sdad)-25 {
+		return false, nil
+	}
+
+	dynamicData := sdad[4:][:sdad[3]]
+
+	if len(dynamicData) == 0 || int(dynamicData[0]) > len(dynamicData)-1 {
+		return false, nil
+	}
+
+	c.iccDynamicNumber = dynamicData[1:][:dynamicData[0]]
+
+	return true, nil
+}
+
+// prepareCda recovers the ICC public key, the dynamic signature itself is
+// only verified when the card answers GENERATE AC.
+func (c *Context) prepareCda() (bool, error) {
+	info := c.CardInformation
+
+	if len(info.IssuerPublicKeyCertificate) == 0 || len(info.IccPublicKeyCertificate) == 0 || len(info.IccPublicKeyExponent) == 0 {
+		c.tvr |= TvrIccDataMissing
+		return false, nil
+	}
+
+	issuer, err := c.retrieveIssuerPublicKey()
+
+	if err != nil {
+		return false, nil
+	}
+
+	icc, err := c.retrieveIccPublicKey(issuer)
+
+	if err != nil {
+		return false, nil
+	}
+
+	c.iccPublicKey = icc
+
+	return true, nil
+}
+
+func (c *Context) generateAC(kind int, dol DataObjectList, tx *Transaction) (*GeneratedAC, error) {
+	data, err := c.buildDol(dol, tx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.cdolData = append(c.cdolData, data...)
+
+	cda := c.iccPublicKey != nil && kind != AcAac
+
+	if cda {
+		kind |= AcCdaRequested
+	}
+
+	ac, err := c.card.GenerateAC(kind, data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cda && ac.CryptogramInformationData&0xC0 != AcAac {
+		if !c.verifyCda(ac) {
+			c.tvr |= TvrCdaFailed
+		}
+	}
+
+	return ac, nil
+}
+
+func (c *Context) verifyCda(ac *GeneratedAC) bool {
+	if ac.SignedDynamicApplicationData == nil {
+		return false
+	}
+
+	sdad, err := c.iccPublicKey.Decrypt(ac.SignedDynamicApplicationData)
+
+	if err != nil {
+		return false
+	}
+
+	if len(sdad) != len(c.iccPublicKey.Modulus()) || len(sdad) < 25 {
+		return false
+	}
+
+	if sdad[0] != 0x6A || sdad[1] != 0x05 || sdad[len(sdad)-1] != 0xBC {
+		return false
+	}
+
+	data := append([]byte{}, sdad[1:len(sdad)-21]...)
+	data = append(data, c.unpredictableNumber...)
+
+	actualHash := sha1.Sum(data)
+	expectedHash := sdad[len(sdad)-21 : len(sdad)-1]
+
+	if !bytes.Equal(expectedHash, actualHash[:]) {
+		return false
+	}
+
+	if int(sdad[3]) > len(sdad)-25 {
+		return false
+	}
+
+	dynamicData := sdad[4:][:sdad[3]]
+
+	if len(dynamicData) == 0 || len(dynamicData) < int(dynamicData[0])+30 {
+		return false
+	}
+
+	number := dynamicData[1:][:dynamicData[0]]
+	cid := dynamicData[1+len(number)]
+	cryptogram := dynamicData[2+len(number):][:8]
+	transactionHash := dynamicData[10+len(number):][:20]
+
+	if uint(cid) != ac.CryptogramInformationData {
+		return false
+	}
+
+	responseData, err := ac.unsignedData()
+
+	if err != nil {
+		return false
+	}
+
+	hashed := append([]byte{}, c.pdolData...)
+	hashed = append(hashed, c.cdolData...)
+	hashed = append(hashed, responseData...)
+
+	actualTransactionHash := sha1.Sum(hashed)
+
+	if !bytes.Equal(transactionHash, actualTransactionHash[:]) {
+		return false
+	}
+
+	c.iccDynamicNumber = number
+	ac.Cryptogram = cryptogram
+
+	return true
+}
+
+func (c *Context) buildSdaTags() ([]byte, error) {
+	result := make([]byte, 0)
+
+	for _, tag := range c.CardInformation.SdaTags {
+		t, found := tlv.Pick(tag, c.CardInformation.Raw, c.ProcessingOptions.Raw)
+
+		if !found {
+			return nil, fmt.Errorf("missing SDA tag")
+		}
+
+		value, _, err := t.Bytes(tag)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, value...)
+	}
+
+	return result, nil
+}
+
+func (c *Context) retrieveIssuerPublicKey() (*PublicKey, error) {
+	rid := c.Application.DedicatedFileName[0:5]
+	index := c.CardInformation.SchemePublicKeyIndex
+
+	pub, err := c.cm.GetSchemePublicKey(rid, index)
+
+	if err != nil {
+		return nil, err
+	}
+
+	issuer, cert, err := recoverPublicKey(pub, 0x02, 15, c.CardInformation.IssuerPublicKeyCertificate, c.CardInformation.IssuerPublicKeyRemainder, c.CardInformation.IssuerPublicKeyExponent, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkCertificate(cert, 15, time.Now())
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The issuer identifier is the leftmost 3 to 8 PAN digits, padded with F
+	id := strings.TrimRight(hex.EncodeToString(cert[2:6]), "f")
+
+	if len(id) < 3 || !strings.HasPrefix(c.CardInformation.Pan, id) {
+		return nil, fmt.Errorf("issuer public key certificate doesn't match the pan")
+	}
+
+	return issuer, nil
+}
+
+func (c *Context) retrieveIccPublicKey(issuer *PublicKey) (*PublicKey, error) {
+	sdaTags, err := c.buildSdaTags()
+
+	if err != nil {
+		return nil, err
+	}
+
+	staticData := append([]byte{}, c.sdaData...)
+	staticData = append(staticData, sdaTags...)
+
+	pub, cert, err := recoverPublicKey(issuer, 0x04, 21, c.CardInformation.IccPublicKeyCertificate, c.CardInformation.IccPublicKeyRemainder, c.CardInformation.IccPublicKeyExponent, staticData)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkCertificate(cert, 21, time.Now())
+
+	if err != nil {
+		return nil, err
+	}
+
+	pan := strings.TrimRight(hex.EncodeToString(cert[2:12]), "f")
+
+	if pan != c.CardInformation.Pan {
+		return nil, fmt.Errorf("icc public key certificate doesn't match the pan")
+	}
+
+	return pub, nil
+}
+
+// checkCertificate checks the algorithm indicators and the expiration date
+// (MMYY) of a recovered public key certificate, where header is the length of
+// the certificate fields preceding the key.
+func checkCertificate(cert []byte, header int, now time.Time) error {
+	// Only SHA-1 and RSA are defined
+	if cert[header-4] != 0x01 || cert[header-3] != 0x01 {
+		return fmt.Errorf("unsupported public key certificate algorithm")
+	}
+
+	expiry := cert[header-9 : header-7]
+	month, err := tlv.DecodeDate([]byte{expiry[1], expiry[0], 0x01})
+
+	if err != nil {
+		return err
+	}
+
+	// The certificate is valid until the last day of the month
+	if dateValue(now) >= dateValue(month.AddDate(0, 1, 0)) {
+		return fmt.Errorf("public key certificate expired")
+	}
+
+	return nil
+}
+
+// retrievePinEnciphermentKey recovers the ICC PIN Encipherment Public Key,
+// falling back to the ICC Public Key when the card doesn't have one.
+func (c *Context) retrievePinEnciphermentKey() (*PublicKey, error) {
+	info := c.CardInformation
+
+	if len(info.IccPinEnciphermentPublicKeyCertificate) == 0 && c.iccPublicKey != nil {
+		return c.iccPublicKey, nil
+	}
+
+	issuer, err := c.retrieveIssuerPublicKey()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(info.IccPinEnciphermentPublicKeyCertificate) == 0 {
+		return c.retrieveIccPublicKey(issuer)
+	}
+
+	pub, cert, err := recoverPublicKey(issuer, 0x04, 21, info.IccPinEnciphermentPublicKeyCertificate, info.IccPinEnciphermentPublicKeyRemainder, info.IccPinEnciphermentPublicKeyExponent, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkCertificate(cert, 21, time.Now())
+
+	if err != nil {
+		return nil, err
+	}
+
+	pan := strings.TrimRight(hex.EncodeToString(cert[2:12]), "f")
+
+	if pan != info.Pan {
+		return nil, fmt.Errorf("icc pin encipherment public key certificate doesn't match the pan")
+	}
+
+	return pub, nil
+}
+
+// recoverPublicKey recovers a public key certified by pub, along with the
+// recovered certificate.
+//
+// header is the length of the certificate fields preceding the key.
+func recoverPublicKey(pub *PublicKey, format byte, header int, certificate, remainder, exponent, extra []byte) (*PublicKey, []byte, error) {
+	cert, err := pub.Decrypt(certificate)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(cert) != len(pub.Modulus()) || len(cert) < header+21 {
+		return nil, nil, fmt.Errorf("invalid public key certificate")
+	}
+
+	if cert[0] != 0x6A || cert[1] != format || cert[len(cert)-1] != 0xBC {
+		return nil, nil, fmt.Errorf("invalid public key certificate")
+	}
+
+	keycheck := append([]byte{}, cert[1:len(cert)-21]...)
+	keycheck = append(keycheck, remainder...)
+	keycheck = append(keycheck, exponent...)
+	keycheck = append(keycheck, extra...)
+
+	expectedKeycheckHash := cert[len(cert)-21 : len(cert)-1]
+	actualKeycheckHash := sha1.Sum(keycheck)
+
+	if !bytes.Equal(expectedKeycheckHash, actualKeycheckHash[:]) {
+		return nil, nil, fmt.Errorf("hash doesn't match")
+	}
+
+	length := int(cert[header-2])
+	modulus := append([]byte{}, cert[header:len(cert)-21]...)
+
+	if length <= len(modulus) {
+		modulus = modulus[:length]
+	} else {
+		modulus = append(modulus, remainder...)
+	}
+
+	if len(modulus) != length {
+		return nil, nil, fmt.Errorf("invalid public key remainder")
+	}
 
 	e := big.NewInt(0)
-	e.SetBytes(c.CardInformation.IssuerPublicKeyExponent)
+	e.SetBytes(exponent)
 
 	m := big.NewInt(0)
 	m.SetBytes(modulus)
 
-	return NewPublicKey(e, m), nil
+	return NewPublicKey(e, m), cert, nil
 }
 
 func (c *Context) generateUnpredictableNumber(size int) ([]byte, error) {
 	data := make([]byte, size)
 
-	_, err := rand.Read(data)
+	_, err := io.ReadFull(c.random, data)
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,865 @@
+package emv
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"github.com/greenboxal/emv-kernel/simulator"
+	"github.com/greenboxal/emv-kernel/tlv"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testAid = []byte{0xA0, 0x00, 0x00, 0x00, 0x03, 0x10, 0x10}
+var testPan = []byte{0x47, 0x61, 0x73, 0x90, 0x01, 0x01, 0x01, 0x19}
+
+type testCertificateManager struct {
+	ca *simulator.CertificateAuthority
+}
+
+func (cm *testCertificateManager) GetSchemePublicKey(rid []byte, index int) (*PublicKey, error) {
+	return NewPublicKey(big.NewInt(int64(cm.ca.Key.E)), cm.ca.Key.N), nil
+}
+
+type testPinAsker struct {
+	pin string
+}
+
+func (t *testPinAsker) RetrievePin() (string, error) {
+	return t.pin, nil
+}
+
+// testKeys holds the key hierarchy shared by every simulated card, generating
+// RSA keys for each test case makes the package tests crawl.
+type testKeys struct {
+	ca     *simulator.CertificateAuthority
+	issuer *simulator.Issuer
+	icc    *rsa.PrivateKey
+	other  *rsa.PrivateKey
+}
+
+var testKeysOnce sync.Once
+var testKeysValue *testKeys
+var testKeysErr error
+
+func loadTestKeys(t *testing.T) *testKeys {
+	testKeysOnce.Do(func() {
+		keys := &testKeys{}
+
+		keys.ca, testKeysErr = simulator.NewCertificateAuthority(testAid[:5], 0x92, 1024)
+
+		if testKeysErr != nil {
+			return
+		}
+
+		keys.issuer, testKeysErr = keys.ca.NewIssuer(1024, []byte{0x47, 0x61, 0x73}, []byte{0x12, 0x49})
+
+		if testKeysErr != nil {
+			return
+		}
+
+		keys.icc, testKeysErr = rsa.GenerateKey(rand.Reader, 1024)
+
+		if testKeysErr != nil {
+			return
+		}
+
+		keys.other, testKeysErr = rsa.GenerateKey(rand.Reader, 1024)
+		testKeysValue = keys
+	})
+
+	assert.Nil(t, testKeysErr)
+
+	return testKeysValue
+}
+
+func newTestProfile(t *testing.T, aip []byte, extra ...[]byte) (*simulator.Profile, CertificateManager) {
+	keys := loadTestKeys(t)
+	pan := testPan
+
+	app := &simulator.Application{
+		Aid:      testAid,
+		Label:    "VISA CREDIT",
+		Priority: 1,
+		Aip:      aip,
+		IccKey:   keys.icc,
+		AcKey:    []byte("0123456789ABCDEF"),
+		Records: []simulator.Record{
+			{Sfi: 1, Number: 1, Sda: true, Data: simulator.Objects(
+				simulator.Object(0x5A, pan),
+				simulator.Object(0x5F24, []byte{0x49, 0x12, 0x31}),
+				simulator.Object(0x5F25, []byte{0x17, 0x01, 0x01}),
+				simulator.Object(0x5F34, []byte{0x01}),
+				simulator.Object(0x5F20, []byte("CARDHOLDER/TEST")),
+				simulator.Object(0x8C, []byte{0x9F, 0x02, 0x06, 0x9F, 0x1A, 0x02, 0x95, 0x05, 0x9A, 0x03, 0x9C, 0x01, 0x9F, 0x37, 0x04}),
+				simulator.Object(0x8D, []byte{0x8A, 0x02, 0x95, 0x05, 0x9F, 0x37, 0x04}),
+				simulator.Object(0x9F4A, []byte{0x82}),
+				simulator.Objects(extra...),
+			)},
+		},
+	}
+
+	certifyTestApplication(app, keys.ca, keys.issuer, pan, []byte{0x12, 0x49})
+
+	profile := &simulator.Profile{
+		Pse:          true,
+		PseSfi:       1,
+		Pin:          "1234",
+		PinTryLimit:  3,
+		Applications: []*simulator.Application{app},
+	}
+
+	return profile, &testCertificateManager{keys.ca}
+}
+
+// certifyTestApplication (re)builds the certificate records of a test
+// application, certifying its ICC key for pan until expiry (MMYY).
+func certifyTestApplication(app *simulator.Application, ca *simulator.CertificateAuthority, issuer *simulator.Issuer, pan, expiry []byte) {
+	app.Records = app.Records[:1]
+
+	staticData := app.StaticData()
+	iccCert, iccRemainder, iccExponent := issuer.CertifyIccKey(&app.IccKey.PublicKey, pan, expiry, staticData)
+
+	app.Records = append(app.Records, simulator.Record{Sfi: 2, Number: 1, Data: simulator.Objects(
+		simulator.Object(0x8F, []byte{byte(ca.Index)}),
+		simulator.Object(0x90, issuer.Certificate),
+		simulator.Object(0x92, issuer.Remainder),
+		simulator.Object(0x9F32, issuer.Exponent),
+		simulator.Object(0x93, issuer.SignStaticData(staticData, []byte{0xDA, 0xC1})),
+	)})
+
+	app.Records = append(app.Records, simulator.Record{Sfi: 2, Number: 2, Data: simulator.Objects(
+		simulator.Object(0x9F46, iccCert),
+		simulator.Object(0x9F47, iccExponent),
+		simulator.Object(0x9F48, iccRemainder),
+	)})
+}
+
+var testTerminal = Terminal{
+	Capabilities: CapPlaintextPin | CapSignature | CapNoCvm | CapSda | CapDda | CapCda,
+	CountryCode:  76,
+	DefaultDdol:  DataObjectList{{0x9F37, 4}},
+}
+
+func newTestContext(t *testing.T, transport Transport, cm CertificateManager) *Context {
+	ctx := NewContext(NewCard(transport), &ContextConfig{
+		Terminal: testTerminal,
+	}, cm)
+
+	assert.Nil(t, ctx.Initialize())
+
+	return ctx
+}
+
+// newSelectedContext builds a context for the profile, lets the test adjust
+// its configuration and selects the test application.
+func newSelectedContext(t *testing.T, profile *simulator.Profile, cm CertificateManager, configure func(config *ContextConfig)) *Context {
+	ctx := newTestContext(t, simulator.NewCard(profile), cm)
+
+	if configure != nil {
+		configure(ctx.config)
+	}
+
+	_, err := ctx.SelectApplication(testAid)
+	assert.Nil(t, err)
+
+	return ctx
+}
+
+func TestContextWithSimulator(t *testing.T) {
+	for _, pse := range []bool{true, false} {
+		profile, cm := newTestProfile(t, []byte{0x40, 0x00})
+		profile.Pse = pse
+
+		ctx := newTestContext(t, simulator.NewCard(profile), cm)
+
+		apps, err := ctx.ListApplications(false, []ApplicationHint{{Name: testAid[:5], Partial: true}})
+		assert.Nil(t, err)
+		assert.Len(t, apps, 1)
+		assert.Equal(t, testAid, apps[0].Name)
+		assert.Equal(t, "VISA CREDIT", apps[0].Label)
+
+		_, err = ctx.SelectApplication(apps[0].Name)
+		assert.Nil(t, err)
+		assert.Equal(t, "4761739001010119", ctx.CardInformation.Pan)
+		assert.Equal(t, 1, ctx.CardInformation.SequenceNumber)
+
+		ok, err := ctx.Authenticate()
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, TVR(0), ctx.tvr&(TvrSdaFailed|TvrOfflineNotPerformed))
+		assert.Equal(t, []byte{0xDA, 0xC1}, ctx.dataAuthenticationCode)
+		assert.Equal(t, TSI(TsiOdaPerformed), ctx.Tsi())
+	}
+}
+
+func TestVerifyCardholderWithSimulator(t *testing.T) {
+	cvmList := func(x, y byte, rules ...byte) []byte {
+		return simulator.Object(0x8E, append([]byte{0, 0, 0, x, 0, 0, 0, y}, rules...))
+	}
+
+	cases := []struct {
+		aip       []byte
+		data      []byte
+		pin       string
+		ok        bool
+		cvr       CVMResults
+		tvr       TVR
+		signature bool
+	}{
+		{[]byte{0x40, 0x00}, cvmList(0, 0, 0x01, 0x00), "1234", false, 0x3F0000, 0, false},
+		{[]byte{0x50, 0x00}, nil, "1234", false, 0x3F0000, TvrIccDataMissing, false},
+		{[]byte{0x50, 0x00}, cvmList(0, 0, 0x01, 0x00), "1234", true, 0x010002, 0, false},
+		{[]byte{0x50, 0x00}, cvmList(0, 0, 0x01, 0x00), "0000", false, 0x010001, TvrCvmFailed, false},
+		{[]byte{0x50, 0x00}, cvmList(0, 0, 0x01, 0x00), "", false, 0x010001, TvrCvmFailed | TvrPinNotEntered, false},
+		{[]byte{0x50, 0x00}, cvmList(0, 0, 0x41, 0x00, 0x1F, 0x00), "0000", true, 0x1F0002, 0, false},
+		{[]byte{0x50, 0x00}, cvmList(0, 0, 0x03, 0x00), "1234", true, 0x030000, 0, true},
+		{[]byte{0x50, 0x00}, cvmList(0, 0, 0x42, 0x00, 0x1E, 0x00), "1234", true, 0x1E0000, TvrNoPinpad, true},
+		{[]byte{0x50, 0x00}, cvmList(0, 0, 0x42, 0x03, 0x1E, 0x03), "1234", true, 0x1E0300, 0, true},
+		{[]byte{0x50, 0x00}, cvmList(0x08, 0, 0x01, 0x06, 0x1F, 0x00), "0000", true, 0x1F0002, 0, false},
+		{[]byte{0x50, 0x00}, cvmList(0x08, 0, 0x01, 0x07, 0x1F, 0x00), "1234", true, 0x010702, 0, false},
+		{[]byte{0x50, 0x00}, cvmList(0, 0, 0x01, 0x05, 0x02, 0x0A), "1234", false, 0x3F0001, TvrCvmFailed, false},
+		{[]byte{0x50, 0x00}, cvmList(0, 0, 0x2A, 0x00), "1234", false, 0x2A0001, TvrCvmFailed | TvrUnrecognizedCvm, false},
+	}
+
+	for _, c := range cases {
+		profile, cm := newTestProfile(t, c.aip, c.data, simulator.Object(0x9F42, []byte{0x09, 0x86}))
+		ctx := newSelectedContext(t, profile, cm, func(config *ContextConfig) {
+			config.Terminal.CurrencyCode = 986
+		})
+
+		ok, err := ctx.VerifyCardholder(&Transaction{Amount: 0x10}, &testPinAsker{c.pin})
+		assert.Nil(t, err)
+		assert.Equal(t, c.ok, ok)
+		assert.Equal(t, c.cvr, ctx.cvr)
+		assert.Equal(t, c.tvr, ctx.tvr)
+		assert.Equal(t, c.signature, ctx.signatureRequired)
+	}
+}
+
+func TestVerifyCardholderPinTryLimit(t *testing.T) {
+	profile, cm := newTestProfile(t, []byte{0x50, 0x00}, simulator.Object(0x8E, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0x01, 0x00}))
+	profile.PinTryLimit = 1
+
+	ctx := newSelectedContext(t, profile, cm, nil)
+
+	ok, err := ctx.VerifyCardholder(&Transaction{}, &testPinAsker{"0000"})
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, TVR(TvrCvmFailed|TvrPinTryLimit), ctx.tvr)
+	assert.Equal(t, TSI(TsiCvmPerformed), ctx.Tsi())
+}
+
+func TestVerifyCardholderEncipheredPin(t *testing.T) {
+	for _, c := range []struct {
+		pin      string
+		ok       bool
+		pinTries int
+	}{
+		{"1234", true, 3},
+		{"0000", false, 2},
+	} {
+		profile, cm := newTestProfile(t, []byte{0x50, 0x00}, simulator.Object(0x8E, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0x04, 0x00}))
+		ctx := newSelectedContext(t, profile, cm, func(config *ContextConfig) {
+			config.Terminal.Capabilities |= CapEncipheredOfflinePin
+		})
+
+		ok, err := ctx.VerifyCardholder(&Transaction{}, &testPinAsker{c.pin})
+		assert.Nil(t, err)
+		assert.Equal(t, c.ok, ok)
+
+		tries, err := ctx.card.GetData(0x9F17)
+		assert.Nil(t, err)
+		assert.Equal(t, []byte{byte(c.pinTries)}, tries)
+	}
+}
+
+func TestVerifyCardholderEncipheredPinWithWrongKey(t *testing.T) {
+	profile, cm := newTestProfile(t, []byte{0x50, 0x00}, simulator.Object(0x8E, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0x04, 0x00}))
+	profile.Applications[0].PinKey = loadTestKeys(t).other
+
+	ctx := newSelectedContext(t, profile, cm, func(config *ContextConfig) {
+		config.Terminal.Capabilities |= CapEncipheredOfflinePin
+	})
+
+	ok, err := ctx.VerifyCardholder(&Transaction{}, &testPinAsker{"1234"})
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.NotEqual(t, TVR(0), ctx.tvr&TvrCvmFailed)
+}
+
+func TestDdaWithSimulator(t *testing.T) {
+	profile, cm := newTestProfile(t, []byte{0x20, 0x00}, simulator.Object(0x9F49, []byte{0x9F, 0x37, 0x04}))
+	ctx := newSelectedContext(t, profile, cm, nil)
+
+	ok, err := ctx.Authenticate()
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, TVR(0), ctx.tvr&(TvrDdaFailed|TvrDefaultTdol))
+	assert.Len(t, ctx.iccDynamicNumber, 8)
+}
+
+func TestSelectApplicationWithInvalidData(t *testing.T) {
+	profile, cm := newTestProfile(t, []byte{0x40, 0x00}, simulator.Object(0x9F08, []byte{0x00, 0x8C, 0x01}))
+	ctx := newSelectedContext(t, profile, cm, nil)
+
+	assert.Equal(t, "4761739001010119", ctx.CardInformation.Pan)
+	assert.Len(t, ctx.ValidationErrors(), 1)
+}
+
+func TestSelectApplicationWithMalformedTemplate(t *testing.T) {
+	profile, cm := newTestProfile(t, []byte{0x40, 0x00}, simulator.Object(0xBF50, []byte{0x88, 0x05, 0x01}))
+	ctx := newSelectedContext(t, profile, cm, nil)
+
+	value, found := ctx.RecordData.Find(0xBF50)
+	assert.True(t, found)
+	assert.Equal(t, []byte{0x88, 0x05, 0x01}, value)
+	assert.Equal(t, "4761739001010119", ctx.CardInformation.Pan)
+}
+
+func TestBuildDol(t *testing.T) {
+	profile, cm := newTestProfile(t, []byte{0x40, 0x00})
+	profile.Applications[0].Pdol = []byte{0x9F, 0x1A, 0x02, 0x5F, 0x2A, 0x02, 0x70, 0x02, 0x9F, 0x7F, 0x01, 0x9F, 0x37, 0x04}
+
+	ctx := newSelectedContext(t, profile, cm, func(config *ContextConfig) {
+		config.Terminal.CurrencyCode = 986
+	})
+
+	assert.Len(t, ctx.pdolData, 11)
+	assert.Equal(t, []byte{0x00, 0x76, 0x09, 0x86, 0x00, 0x00, 0x00}, ctx.pdolData[:7])
+	assert.Equal(t, ctx.unpredictableNumber, ctx.pdolData[7:])
+
+	dol := DataObjectList{{0x9F02, 4}, {0x9A, 3}, {0x5F20, 4}, {0x5A, 10}, {0x9F03, 7}}
+
+	data, err := ctx.buildDol(dol, &Transaction{Amount: 123456, Date: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{
+		0x00, 0x12, 0x34, 0x56,
+		0x26, 0x10, 0x17,
+		'C', 'A', 'R', 'D',
+		0x47, 0x61, 0x73, 0x90, 0x01, 0x01, 0x01, 0x19, 0xFF, 0xFF,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}, data)
+
+	ctx.config.Terminal.Other = tlv.Tlv{0x9F1E: []byte("TERM0001"), 0x9F66: {0x00}}
+	ctx.config.DataSource = testDataSource{0x9F66: {0x36, 0x00, 0x40, 0x00}}
+	ctx.tvr = TvrHotlist
+
+	dol = DataObjectList{{0x9F1E, 8}, {0x9F66, 4}, {0x9F21, 3}, {0x95, 5}, {0x82, 2}, {0x9F35, 1}}
+
+	data, err = ctx.buildDol(dol, &Transaction{Date: time.Date(2026, 10, 17, 13, 45, 30, 0, time.UTC)})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("TERM0001"), data[:8])
+	assert.Equal(t, []byte{0x36, 0x00, 0x40, 0x00}, data[8:12])
+	assert.Equal(t, []byte{0x13, 0x45, 0x30}, data[12:15])
+	assert.Equal(t, TvrHotlist.Bytes(), data[15:20])
+	assert.Equal(t, []byte{0x40, 0x00}, data[20:22])
+	assert.Equal(t, []byte{0x00}, data[22:])
+}
+
+func TestTerminalProfile(t *testing.T) {
+	profile, cm := newTestProfile(t, []byte{0x40, 0x00})
+	profile.Applications[0].LanguagePreference = "deenpt"
+
+	ctx := newSelectedContext(t, profile, cm, func(config *ContextConfig) {
+		config.Terminal.Languages = []string{"pt", "en"}
+		config.Terminal.TerminalIdentification = "TERM01"
+		config.Terminal.MerchantCategoryCode = 5411
+		config.Terminal.CurrencyExponent = 2
+		config.Terminal.DefaultTdol = DataObjectList{{0x9F02, 6}, {0x98, 20}}
+	})
+
+	assert.Equal(t, "en", ctx.Language())
+
+	dol := DataObjectList{{0x9F1C, 8}, {0x9F15, 2}, {0x5F36, 1}, {0x9F16, 15}, {0x98, 20}}
+	tx := &Transaction{Amount: 1000}
+
+	data, err := ctx.buildDol(dol, tx)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{'T', 'E', 'R', 'M', '0', '1', 0x00, 0x00}, data[:8])
+	assert.Equal(t, []byte{0x54, 0x11, 0x02}, data[8:11])
+	assert.Equal(t, make([]byte, 15), data[11:26])
+
+	hash := sha1.Sum([]byte{0x00, 0x00, 0x00, 0x00, 0x10, 0x00})
+	assert.Equal(t, hash[:], data[26:])
+	assert.True(t, ctx.Tvr().Has(TvrDefaultTdol))
+}
+
+func TestTransactionData(t *testing.T) {
+	cases := []struct {
+		kind     int
+		expected byte
+	}{
+		{TransactionTypePurchase, 0x00},
+		{TransactionTypeCash, 0x01},
+		{TransactionTypeCashback, 0x09},
+		{TransactionTypeRefund, 0x20},
+	}
+
+	for _, c := range cases {
+		value, found, err := (&Transaction{Type: c.kind}).Data(0x9C)
+		assert.Nil(t, err)
+		assert.True(t, found)
+		assert.Equal(t, []byte{c.expected}, value)
+	}
+}
+
+type testDataSource tlv.Tlv
+
+func (s testDataSource) Data(tag int) ([]byte, bool, error) {
+	value, found := s[tag]
+
+	return value, found, nil
+}
+
+func TestDdaWithDefaultDdol(t *testing.T) {
+	profile, cm := newTestProfile(t, []byte{0x20, 0x00})
+	ctx := newSelectedContext(t, profile, cm, nil)
+
+	ok, err := ctx.Authenticate()
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, TVR(0), ctx.tvr&(TvrDdaFailed|TvrDefaultTdol))
+}
+
+func TestDdaWithWrongIccKey(t *testing.T) {
+	profile, cm := newTestProfile(t, []byte{0x20, 0x00})
+	profile.Applications[0].IccKey = loadTestKeys(t).other
+
+	ctx := newSelectedContext(t, profile, cm, nil)
+
+	ok, err := ctx.Authenticate()
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.NotEqual(t, TVR(0), ctx.tvr&TvrDdaFailed)
+}
+
+func TestCertificateChecks(t *testing.T) {
+	keys := loadTestKeys(t)
+
+	expired, err := keys.ca.NewIssuer(1024, []byte{0x47, 0x61, 0x73}, []byte{0x12, 0x17})
+	assert.Nil(t, err)
+
+	foreign, err := keys.ca.NewIssuer(1024, []byte{0x51, 0x00, 0x00}, []byte{0x12, 0x49})
+	assert.Nil(t, err)
+
+	cases := []struct {
+		issuer *simulator.Issuer
+		pan    []byte
+		expiry []byte
+		ok     bool
+	}{
+		{keys.issuer, testPan, []byte{0x12, 0x49}, true},
+		{expired, testPan, []byte{0x12, 0x49}, false},
+		{foreign, testPan, []byte{0x12, 0x49}, false},
+		{keys.issuer, testPan, []byte{0x01, 0x20}, false},
+		{keys.issuer, []byte{0x47, 0x61, 0x73, 0x90, 0x01, 0x01, 0x01, 0x10}, []byte{0x12, 0x49}, false},
+	}
+
+	for _, c := range cases {
+		for _, method := range []struct {
+			aip    []byte
+			failed TVR
+		}{
+			{[]byte{0x20, 0x00}, TvrDdaFailed},
+			{[]byte{0x21, 0x00}, TvrCdaFailed},
+		} {
+			profile, cm := newTestProfile(t, method.aip)
+			certifyTestApplication(profile.Applications[0], keys.ca, c.issuer, c.pan, c.expiry)
+
+			ctx := newSelectedContext(t, profile, cm, nil)
+
+			ok, err := ctx.Authenticate()
+			assert.Nil(t, err)
+			assert.Equal(t, c.ok, ok)
+			assert.Equal(t, !c.ok, ctx.Tvr().Has(method.failed))
+		}
+	}
+}
+
+func TestCheckCertificate(t *testing.T) {
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		expiry    []byte
+		algorithm byte
+		ok        bool
+	}{
+		{[]byte{0x10, 0x26}, 0x01, true},
+		{[]byte{0x09, 0x26}, 0x01, false},
+		{[]byte{0x13, 0x26}, 0x01, false},
+		{[]byte{0x12, 0x49}, 0x02, false},
+	}
+
+	for _, c := range cases {
+		cert := make([]byte, 15)
+		copy(cert[6:8], c.expiry)
+		cert[11] = c.algorithm
+		cert[12] = 0x01
+
+		assert.Equal(t, c.ok, checkCertificate(cert, 15, now) == nil)
+	}
+}
+
+func TestCdaWithSimulator(t *testing.T) {
+	profile, cm := newTestProfile(t, []byte{0x21, 0x00})
+	ctx := newSelectedContext(t, profile, cm, nil)
+	tx := &Transaction{Amount: 1000, Date: time.Now()}
+
+	ok, err := ctx.Authenticate()
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ac, err := ctx.generateAC(AcArqc, ctx.CardInformation.RiskManagementData, tx)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(AcArqc), ac.CryptogramInformationData)
+	assert.Len(t, ac.Cryptogram, 8)
+	assert.Len(t, ctx.iccDynamicNumber, 8)
+
+	ac, err = ctx.generateAC(AcTc, ctx.CardInformation.IssuerRiskManagementData, tx)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(AcTc), ac.CryptogramInformationData)
+	assert.Len(t, ac.Cryptogram, 8)
+	assert.Equal(t, TVR(0), ctx.tvr&TvrCdaFailed)
+}
+
+func TestCdaWithWrongIccKey(t *testing.T) {
+	profile, cm := newTestProfile(t, []byte{0x21, 0x00})
+	ctx := newSelectedContext(t, profile, cm, nil)
+	tx := &Transaction{Amount: 1000, Date: time.Now()}
+
+	ok, err := ctx.Authenticate()
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	profile.Applications[0].IccKey = loadTestKeys(t).other
+
+	ac, err := ctx.generateAC(AcTc, ctx.CardInformation.RiskManagementData, tx)
+	assert.Nil(t, err)
+	assert.Nil(t, ac.Cryptogram)
+	assert.NotEqual(t, TVR(0), ctx.tvr&TvrCdaFailed)
+}
+
+func TestGenerateCryptogramWithCdaFailure(t *testing.T) {
+	profile, cm := newTestProfile(t, []byte{0x21, 0x00})
+	profile.Applications[0].ForceOnline = true
+
+	ctx := newSelectedContext(t, profile, cm, nil)
+	tx := &Transaction{Amount: 1000, Date: time.Now()}
+
+	ok, err := ctx.Authenticate()
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	profile.Applications[0].IccKey = loadTestKeys(t).other
+
+	result, err := ctx.GenerateCryptogram(tx)
+	assert.Nil(t, err)
+	assert.Equal(t, AacCryptogram, result.CryptogramType)
+	assert.False(t, result.Approved)
+	assert.False(t, result.ShouldGoOnline)
+	assert.True(t, ctx.Tvr().Has(TvrCdaFailed))
+
+	// The card no longer has a transaction waiting for the second GENERATE AC
+	_, err = ctx.card.GenerateAC(AcAac, nil)
+	assert.NotNil(t, err)
+
+	_, err = ctx.CompleteTransaction(tx, []byte("00"))
+	assert.NotNil(t, err)
+}
+
+func TestGenerateCryptogramWithSimulator(t *testing.T) {
+	for _, format1 := range []bool{false, true} {
+		profile, cm := newTestProfile(t, []byte{0x40, 0x00})
+		profile.Applications[0].Format1 = format1
+
+		ctx := newSelectedContext(t, profile, cm, nil)
+		tx := &Transaction{Amount: 1000, Date: time.Now()}
+
+		result, err := ctx.GenerateCryptogram(tx)
+		assert.Nil(t, err)
+		assert.True(t, result.Approved)
+		assert.Equal(t, TcCryptogram, result.CryptogramType)
+		assert.Len(t, result.Cryptogram, 8)
+		assert.Equal(t, uint(1), result.ApplicationTransactionCounter)
+		assert.NotEmpty(t, result.IssuerApplicationData)
+
+		_, err = ctx.CompleteTransaction(tx, []byte("00"))
+		assert.NotNil(t, err)
+	}
+}
+
+func TestCompleteTransactionWithSimulator(t *testing.T) {
+	for _, arc := range [][]byte{[]byte("00"), []byte("05"), nil} {
+		profile, cm := newTestProfile(t, []byte{0x40, 0x00})
+		profile.Applications[0].ForceOnline = true
+
+		ctx := newSelectedContext(t, profile, cm, nil)
+		tx := &Transaction{Amount: 1000, Date: time.Now()}
+
+		result, err := ctx.GenerateCryptogram(tx)
+		assert.Nil(t, err)
+		assert.True(t, result.ShouldGoOnline)
+		assert.Equal(t, ArqcCryptogram, result.CryptogramType)
+
+		result, err = ctx.CompleteTransaction(tx, arc)
+		assert.Nil(t, err)
+		assert.False(t, result.ShouldGoOnline)
+		assert.Equal(t, string(arc) != "05", result.Approved)
+	}
+}
+
+func TestProcessOnlineResponseWithSimulator(t *testing.T) {
+	cdol2 := []byte{0x8A, 0x02, 0x95, 0x05, 0x9F, 0x37, 0x04}
+
+	for _, c := range []struct {
+		cdol2    []byte
+		valid    bool
+		approved bool
+	}{
+		{nil, true, true},
+		{nil, false, false},
+		{append([]byte{0x91, 0x0A}, cdol2...), true, true},
+		{append([]byte{0x91, 0x0A}, cdol2...), false, false},
+	} {
+		profile, cm := newTestProfile(t, []byte{0x04, 0x00})
+		app := profile.Applications[0]
+		app.ForceOnline = true
+
+		if c.cdol2 != nil {
+			app.Records[0].Data = bytes.Replace(app.Records[0].Data, simulator.Object(0x8D, cdol2), simulator.Object(0x8D, c.cdol2), 1)
+		}
+
+		ctx := newSelectedContext(t, profile, cm, nil)
+		tx := &Transaction{Amount: 1000, Date: time.Now()}
+
+		result, err := ctx.GenerateCryptogram(tx)
+		assert.Nil(t, err)
+		assert.True(t, result.ShouldGoOnline)
+
+		arc := []byte("00")
+		arpc := app.Arpc(result.Cryptogram, arc)
+
+		if !c.valid {
+			arpc[0] ^= 0xFF
+		}
+
+		result, err = ctx.ProcessOnlineResponse(tx, &OnlineResponse{
+			AuthorisationResponseCode: arc,
+			IssuerAuthenticationData:  append(arpc, arc...),
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, c.approved, result.Approved)
+		assert.Equal(t, c.valid || c.cdol2 != nil, ctx.tvr&TvrIssuerAuthFailed == 0)
+		assert.Equal(t, TSI(TsiCardRiskManagement|TsiIssuerAuthentication), ctx.Tsi())
+	}
+}
+
+func TestIssuerScriptsWithSimulator(t *testing.T) {
+	profile, cm := newTestProfile(t, []byte{0x00, 0x00})
+	profile.Applications[0].ForceOnline = true
+
+	ctx := newSelectedContext(t, profile, cm, nil)
+	tx := &Transaction{Amount: 1000, Date: time.Now()}
+
+	result, err := ctx.GenerateCryptogram(tx)
+	assert.Nil(t, err)
+	assert.True(t, result.ShouldGoOnline)
+
+	scripts, err := DecodeIssuerScripts(simulator.Objects(
+		simulator.Object(0x71, simulator.Objects(
+			simulator.Object(0x9F18, []byte{0x01, 0x02, 0x03, 0x04}),
+			simulator.Object(0x86, []byte{0x04, 0xDA, 0x9F, 0x52, 0x01, 0x42}),
+		)),
+		simulator.Object(0x72, simulator.Object(0x86, []byte{0x84, 0x24, 0x00, 0x00})),
+		simulator.Object(0x72, simulator.Objects(
+			simulator.Object(0x9F18, []byte{0x05, 0x06, 0x07, 0x08}),
+			simulator.Object(0x86, []byte{0x84, 0x24, 0x00, 0x00}),
+			simulator.Object(0x86, []byte{0x84, 0x24, 0x00, 0x01}),
+			simulator.Object(0x86, []byte{0x84, 0x1E, 0x00, 0x00}),
+		)),
+	))
+	assert.Nil(t, err)
+	assert.Len(t, scripts, 3)
+	assert.Len(t, scripts[2].Commands, 3)
+
+	result, err = ctx.ProcessOnlineResponse(tx, &OnlineResponse{
+		AuthorisationResponseCode: []byte("00"),
+		IssuerScripts:             scripts,
+	})
+	assert.Nil(t, err)
+	assert.True(t, result.Approved)
+	assert.Equal(t, []byte{
+		0x20, 0x01, 0x02, 0x03, 0x04,
+		0x20, 0x00, 0x00, 0x00, 0x00,
+		0x12, 0x05, 0x06, 0x07, 0x08,
+	}, result.IssuerScriptResults)
+	assert.Equal(t, TVR(TvrScriptFailedAfterAC), ctx.tvr&(TvrScriptFailedBeforeAC|TvrScriptFailedAfterAC))
+	assert.Equal(t, TSI(TsiCardRiskManagement|TsiScriptProcessing), ctx.Tsi())
+	assert.False(t, profile.Applications[0].Blocked)
+
+	value, err := ctx.card.GetData(0x9F52)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x42}, value)
+}
+
+func TestTerminalActionAnalysis(t *testing.T) {
+	cases := []struct {
+		terminalType int
+		tac          TacSet
+		iac          []byte
+		expected     CryptogramType
+	}{
+		{0x22, TacSet{}, nil, ArqcCryptogram},
+		{0x22, TacSet{Online: TvrOfflineNotPerformed}, nil, ArqcCryptogram},
+		{0x22, TacSet{Denial: TvrOfflineNotPerformed, Online: TvrOfflineNotPerformed}, nil, AacCryptogram},
+		{0x22, TacSet{}, simulator.Object(0x9F0E, []byte{0x80, 0x00, 0x00, 0x00, 0x00}), AacCryptogram},
+		{0x22, TacSet{}, simulator.Object(0x9F0F, []byte{0x00, 0x00, 0x00, 0x00, 0x00}), TcCryptogram},
+		{0x23, TacSet{Online: TvrOfflineNotPerformed}, nil, AacCryptogram},
+		{0x23, TacSet{Online: TvrOfflineNotPerformed}, simulator.Object(0x9F0D, []byte{0x00, 0x00, 0x00, 0x00, 0x00}), TcCryptogram},
+	}
+
+	for _, c := range cases {
+		profile, cm := newTestProfile(t, []byte{0x00, 0x00}, c.iac)
+		ctx := newSelectedContext(t, profile, cm, func(config *ContextConfig) {
+			config.Terminal.Type = c.terminalType
+			config.Applications = []*ApplicationConfig{{Aid: testAid[:5], Tac: c.tac}}
+		})
+
+		_, err := ctx.Authenticate()
+		assert.Nil(t, err)
+
+		assert.Equal(t, c.expected, ctx.TerminalActionAnalysis())
+
+		result, err := ctx.GenerateCryptogram(&Transaction{Amount: 1000, Date: time.Now()})
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, result.CryptogramType)
+	}
+}
+
+func TestProcessRestrictions(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	}
+
+	cases := []struct {
+		data     []byte
+		tx       *Transaction
+		expected TVR
+	}{
+		{nil, &Transaction{Date: date(2026, 10, 17)}, 0},
+		{nil, &Transaction{Date: date(2016, 12, 31)}, TvrNotYetEffective},
+		{nil, &Transaction{Date: date(2049, 12, 31)}, 0},
+		{nil, &Transaction{Date: date(2050, 1, 1)}, TvrExpiredApplication},
+		{nil, &Transaction{}, 0},
+		{simulator.Object(0x9F08, []byte{0x00, 0x8C}), &Transaction{Date: date(2026, 10, 17)}, TvrDifferentVersions},
+		{simulator.Object(0x9F08, []byte{0x00, 0x8D}), &Transaction{Date: date(2026, 10, 17)}, 0},
+		{simulator.Objects(
+			simulator.Object(0x9F07, []byte{0xAB, 0x00}),
+			simulator.Object(0x5F28, []byte{0x00, 0x76}),
+		), &Transaction{Date: date(2026, 10, 17)}, 0},
+		{simulator.Objects(
+			simulator.Object(0x9F07, []byte{0xAB, 0x00}),
+			simulator.Object(0x5F28, []byte{0x08, 0x40}),
+		), &Transaction{Date: date(2026, 10, 17)}, TvrNotProductAllowed},
+		{simulator.Objects(
+			simulator.Object(0x9F07, []byte{0xFF, 0x00}),
+			simulator.Object(0x5F28, []byte{0x00, 0x76}),
+		), &Transaction{Type: TransactionTypeCashback, Date: date(2026, 10, 17)}, TvrNotProductAllowed},
+		{simulator.Object(0x9F07, []byte{0xFD, 0xC0}), &Transaction{Date: date(2026, 10, 17)}, 0},
+		{simulator.Object(0x9F07, []byte{0xFE, 0xC0}), &Transaction{Date: date(2026, 10, 17)}, TvrNotProductAllowed},
+	}
+
+	for _, c := range cases {
+		profile, cm := newTestProfile(t, []byte{0x40, 0x00}, c.data)
+		ctx := newSelectedContext(t, profile, cm, func(config *ContextConfig) {
+			config.Applications = []*ApplicationConfig{{Aid: testAid[:5], Version: []byte{0x00, 0x8D}}}
+		})
+
+		assert.Nil(t, ctx.ProcessRestrictions(c.tx))
+		assert.Equal(t, c.expected, ctx.tvr)
+	}
+}
+
+func TestManageRisk(t *testing.T) {
+	limits := simulator.Objects(
+		simulator.Object(0x9F14, []byte{0x02}),
+		simulator.Object(0x9F23, []byte{0x05}),
+	)
+
+	cases := []struct {
+		data          []byte
+		atc           int
+		lastOnlineAtc int
+		amount        int
+		random        byte
+		expected      TVR
+	}{
+		{nil, 0, 0, 10000, 0x00, TvrFloorLimit},
+		{nil, 0, 0, 1000, 0x00, TvrRandomOnline},
+		{nil, 0, 0, 1000, 0x62, 0},
+		{nil, 0, 0, 7500, 0x31, TvrRandomOnline},
+		{nil, 0, 0, 7500, 0x32, 0},
+		{limits, 1, 1, 1000, 0x62, 0},
+		{limits, 3, 1, 1000, 0x62, TvrOfflineLowerLimit},
+		{limits, 10, 1, 1000, 0x62, TvrOfflineLowerLimit | TvrOfflineUpperLimit},
+		{limits, 0, 0, 1000, 0x62, TvrNewCard},
+		{limits, 4, 5, 1000, 0x62, TvrOfflineLowerLimit | TvrOfflineUpperLimit},
+	}
+
+	for _, c := range cases {
+		profile, cm := newTestProfile(t, []byte{0x08, 0x00}, c.data)
+		profile.Applications[0].Atc = c.atc
+		profile.Applications[0].LastOnlineAtc = c.lastOnlineAtc
+
+		ctx := newSelectedContext(t, profile, cm, func(config *ContextConfig) {
+			config.Applications = []*ApplicationConfig{{
+				Aid:                 testAid[:5],
+				FloorLimit:          10000,
+				Threshold:           5000,
+				TargetPercentage:    20,
+				MaxTargetPercentage: 80,
+			}}
+		})
+
+		ctx.random = bytes.NewReader([]byte{c.random})
+
+		assert.Nil(t, ctx.ManageRisk(&Transaction{Amount: c.amount}))
+		assert.Equal(t, c.expected, ctx.tvr)
+		assert.Equal(t, TSI(TsiTerminalRiskManagement), ctx.Tsi())
+	}
+}
+
+type testExceptionFile map[string]int
+
+func (t testExceptionFile) Contains(pan string, sequenceNumber int) (bool, error) {
+	number, found := t[pan]
+
+	return found && number == sequenceNumber, nil
+}
+
+func TestManageRiskExceptionFile(t *testing.T) {
+	for _, c := range []struct {
+		file     testExceptionFile
+		expected TVR
+	}{
+		{testExceptionFile{"4761739001010119": 1}, TvrHotlist},
+		{testExceptionFile{"4761739001010119": 2}, 0},
+		{testExceptionFile{"4761739001010010": 1}, 0},
+	} {
+		profile, cm := newTestProfile(t, []byte{0x08, 0x00})
+		ctx := newSelectedContext(t, profile, cm, func(config *ContextConfig) {
+			config.ExceptionFile = c.file
+			config.Applications = []*ApplicationConfig{{Aid: testAid[:5], FloorLimit: 10000}}
+		})
+
+		ctx.random = bytes.NewReader([]byte{0x62})
+
+		assert.Nil(t, ctx.ManageRisk(&Transaction{Amount: 1000}))
+		assert.Equal(t, c.expected, ctx.tvr)
+	}
+}
@@ -1,6 +1,18 @@
 package emv
 
+import "io"
+
 type ContextConfig struct {
 	Terminal     Terminal
 	Applications []*ApplicationConfig
+
+	// Source of terminal random data, crypto/rand when nil
+	Random io.Reader
+
+	// Terminal exception file, not checked when nil
+	ExceptionFile ExceptionFile
+
+	// Additional terminal data, consulted before the Terminal when
+	// building DOL related data
+	DataSource DataSource
 }
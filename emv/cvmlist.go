@@ -0,0 +1,44 @@
+package emv
+
+import (
+	"errors"
+	"github.com/greenboxal/emv-kernel/tlv"
+)
+
+type CvmList struct {
+	X     int
+	Y     int
+	Rules []CvmRule
+}
+
+func (cl *CvmList) DecodeTlv(data []byte) error {
+	if len(data) < 8 || len(data)%2 != 0 {
+		return errors.New("invalid cvm list")
+	}
+
+	x, err := tlv.DecodeUInt(data[0:4])
+
+	if err != nil {
+		return err
+	}
+
+	y, err := tlv.DecodeUInt(data[4:8])
+
+	if err != nil {
+		return err
+	}
+
+	cl.X = int(x)
+	cl.Y = int(y)
+	cl.Rules = make([]CvmRule, 0, (len(data)-8)/2)
+
+	for i := 8; i < len(data); i += 2 {
+		cl.Rules = append(cl.Rules, CvmRule{
+			Method:          int(data[i] & 0x3F),
+			Condition:       int(data[i+1]),
+			ApplySucceeding: data[i]&0x40 != 0,
+		})
+	}
+
+	return nil
+}
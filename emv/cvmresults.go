@@ -0,0 +1,91 @@
+package emv
+
+import "fmt"
+
+// CVMResults holds the Cardholder Verification Method Results (9F34): the
+// method code and condition of the last performed rule, and its result.
+type CVMResults uint64
+
+var cvmMethodNames = map[int]string{
+	CvmFail:                      "Fail CVM processing",
+	CvmPlaintextPin:              "Plaintext PIN verified by ICC",
+	CvmEncipheredOnlinePin:       "Enciphered PIN verified online",
+	CvmPlaintextPinAndSignature:  "Plaintext PIN verified by ICC and signature",
+	CvmEncipheredPin:             "Enciphered PIN verified by ICC",
+	CvmEncipheredPinAndSignature: "Enciphered PIN verified by ICC and signature",
+	CvmSignature:                 "Signature",
+	CvmNoCvm:                     "No CVM required",
+	CvmNotPerformed:              "No CVM performed",
+}
+
+var cvmConditionNames = map[int]string{
+	CvmConditionAlways:            "Always",
+	CvmConditionUnattendedCash:    "If unattended cash",
+	CvmConditionNotCash:           "If not unattended cash and not manual cash and not cashback",
+	CvmConditionSupported:         "If terminal supports the CVM",
+	CvmConditionManualCash:        "If manual cash",
+	CvmConditionCashback:          "If cashback",
+	CvmConditionUnderX:            "If under X value",
+	CvmConditionOverX:             "If over X value",
+	CvmConditionUnderY:            "If under Y value",
+	CvmConditionOverY:             "If over Y value",
+	CvmConditionAlwaysUnsupported: "Not applicable",
+}
+
+var cvmResultNames = map[int]string{
+	CvmResultUnknown:    "Unknown",
+	CvmResultFailed:     "Failed",
+	CvmResultSuccessful: "Successful",
+}
+
+func NewCVMResults(code, condition, result int) CVMResults {
+	return CVMResults(code&0xFF)<<16 | CVMResults(condition&0xFF)<<8 | CVMResults(result&0xFF)
+}
+
+// Method returns the CVM code without the apply succeeding bit.
+func (r CVMResults) Method() int {
+	return int(r>>16) & 0x3F
+}
+
+func (r CVMResults) Condition() int {
+	return int(r>>8) & 0xFF
+}
+
+func (r CVMResults) Result() int {
+	return int(r) & 0xFF
+}
+
+func (r CVMResults) Bytes() []byte {
+	return encodeBits(uint64(r), 3)
+}
+
+func (r CVMResults) EncodeTlv() ([]byte, error) {
+	return r.Bytes(), nil
+}
+
+func (r *CVMResults) DecodeTlv(data []byte) error {
+	value, err := decodeBits(data, 3)
+
+	if err != nil {
+		return err
+	}
+
+	*r = CVMResults(value)
+
+	return nil
+}
+
+func (r CVMResults) String() string {
+	return fmt.Sprintf("%s, %s, %s",
+		describeCode(r.Method(), cvmMethodNames),
+		describeCode(r.Condition(), cvmConditionNames),
+		describeCode(r.Result(), cvmResultNames))
+}
+
+func describeCode(code int, names map[int]string) string {
+	if name, found := names[code]; found {
+		return name
+	}
+
+	return fmt.Sprintf("Unknown (%02X)", code)
+}
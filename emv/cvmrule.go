@@ -0,0 +1,45 @@
+package emv
+
+const (
+	CvmFail                      = 0x00
+	CvmPlaintextPin              = 0x01
+	CvmEncipheredOnlinePin       = 0x02
+	CvmPlaintextPinAndSignature  = 0x03
+	CvmEncipheredPin             = 0x04
+	CvmEncipheredPinAndSignature = 0x05
+	CvmSignature                 = 0x1E
+	CvmNoCvm                     = 0x1F
+	CvmNotPerformed              = 0x3F
+
+	CvmConditionAlways            = 0x00
+	CvmConditionUnattendedCash    = 0x01
+	CvmConditionNotCash           = 0x02
+	CvmConditionSupported         = 0x03
+	CvmConditionManualCash        = 0x04
+	CvmConditionCashback          = 0x05
+	CvmConditionUnderX            = 0x06
+	CvmConditionOverX             = 0x07
+	CvmConditionUnderY            = 0x08
+	CvmConditionOverY             = 0x09
+	CvmConditionAlwaysUnsupported = 0xFF
+
+	CvmResultUnknown    = 0x00
+	CvmResultFailed     = 0x01
+	CvmResultSuccessful = 0x02
+)
+
+type CvmRule struct {
+	Method          int
+	Condition       int
+	ApplySucceeding bool
+}
+
+func (r CvmRule) code() int {
+	code := r.Method
+
+	if r.ApplySucceeding {
+		code |= 0x40
+	}
+
+	return code
+}
@@ -2,20 +2,24 @@ package emv
 
 import "github.com/greenboxal/emv-kernel/tlv"
 
-type DataObjectList map[int]int
+// DataObject is an entry of a DOL, the tag and length of a value the card
+// expects from the terminal.
+type DataObject struct {
+	Tag    int
+	Length int
+}
 
-func (dolPointer *DataObjectList) DecodeTlv(data []byte) error {
-	if *dolPointer == nil {
-		*dolPointer = make(DataObjectList)
-	}
+// DataObjectList is a DOL, kept in the order the card asks for the values.
+type DataObjectList []DataObject
 
-	dol := *dolPointer
+func (dolPointer *DataObjectList) DecodeTlv(data []byte) error {
+	dol := make(DataObjectList, 0)
 
 	for i := 0; i < len(data); {
 		tag, tagLength, err := tlv.DecodeTag(data[i:])
 
 		if err != nil {
-			return err
+			return tlv.ShiftError(err, i)
 		}
 
 		i += tagLength
@@ -23,13 +27,62 @@ func (dolPointer *DataObjectList) DecodeTlv(data []byte) error {
 		length, lengthLength, err := tlv.DecodeLength(data[i:])
 
 		if err != nil {
-			return err
+			return tlv.ShiftError(err, i)
 		}
 
 		i += lengthLength
 
-		dol[int(tag)] = int(length)
+		dol = append(dol, DataObject{Tag: tag, Length: int(length)})
 	}
 
+	*dolPointer = dol
+
 	return nil
 }
+
+// Has reports whether the DOL asks for tag.
+func (dol DataObjectList) Has(tag int) bool {
+	for _, object := range dol {
+		if object.Tag == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Encode builds the DOL related data from the values returned by lookup.
+//
+// Values are fitted to the requested lengths (EMV Book 3, section 5.4).
+// Values that are missing, unknown or constructed are sent as zeroes.
+func (dol DataObjectList) Encode(lookup func(tag, length int) ([]byte, error)) ([]byte, error) {
+	data := make([]byte, 0)
+
+	for _, object := range dol {
+		if tlv.IsConstructed(object.Tag) {
+			data = append(data, make([]byte, object.Length)...)
+			continue
+		}
+
+		value, err := lookup(object.Tag, object.Length)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if value == nil {
+			data = append(data, make([]byte, object.Length)...)
+			continue
+		}
+
+		format := tlv.FormatB
+
+		if e, found := tlv.Lookup(object.Tag); found {
+			format = e.Format
+		}
+
+		data = append(data, format.Fit(value, object.Length)...)
+	}
+
+	return data, nil
+}
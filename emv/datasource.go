@@ -0,0 +1,9 @@
+package emv
+
+// DataSource supplies terminal data elements for the DOL related data, eg.
+// the Terminal Transaction Qualifiers (9F66) of a contactless reader.
+type DataSource interface {
+	// Data returns the value of tag, or false when the source doesn't
+	// have it.
+	Data(tag int) ([]byte, bool, error)
+}
@@ -0,0 +1,7 @@
+package emv
+
+// ExceptionFile is the terminal list of blocked cards (hotlist), checked
+// during terminal risk management.
+type ExceptionFile interface {
+	Contains(pan string, sequenceNumber int) (bool, error)
+}
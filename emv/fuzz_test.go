@@ -0,0 +1,28 @@
+package emv
+
+import "testing"
+
+func FuzzDataObjectList(f *testing.F) {
+	f.Add([]byte{0x9F, 0x02, 0x06, 0x9F, 0x03, 0x06, 0x95, 0x05, 0x9F, 0x37, 0x04})
+	f.Add([]byte{0xDF, 0x81, 0x17, 0x81, 0x01})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		dol := DataObjectList{}
+		dol.DecodeTlv(data)
+
+		tl := TagList{}
+		tl.DecodeTlv(data)
+	})
+}
+
+func FuzzApplicationFileList(f *testing.F) {
+	f.Add([]byte{0x08, 0x01, 0x01, 0x00, 0x10, 0x01, 0x03, 0x01})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		afl := ApplicationFileList{}
+		afl.DecodeTlv(data)
+
+		cvm := CvmList{}
+		cvm.DecodeTlv(data)
+	})
+}
@@ -3,5 +3,36 @@ package emv
 import "github.com/greenboxal/emv-kernel/tlv"
 
 type GeneratedAC struct {
+	CryptogramInformationData     uint   `tlv:"9F27"`
+	ApplicationTransactionCounter uint   `tlv:"9F36"`
+	Cryptogram                    []byte `tlv:"9F26"`
+	IssuerApplicationData         []byte `tlv:"9F10"`
+	SignedDynamicApplicationData  []byte `tlv:"9F4B"`
+
 	Raw tlv.Tlv `tlv:"other"`
+
+	template []byte
+}
+
+func (ac *GeneratedAC) CryptogramType() CryptogramType {
+	switch ac.CryptogramInformationData & 0xC0 {
+	case AcTc:
+		return TcCryptogram
+	case AcArqc:
+		return ArqcCryptogram
+	}
+
+	return AacCryptogram
+}
+
+// unsignedData returns the data objects of the response template, in the
+// order returned by the card, except the Signed Dynamic Application Data.
+func (ac *GeneratedAC) unsignedData() ([]byte, error) {
+	list, err := tlv.DecodeList(ac.template)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return list.Without(0x9F4B).Encode(), nil
 }
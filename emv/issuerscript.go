@@ -0,0 +1,74 @@
+package emv
+
+import "github.com/greenboxal/emv-kernel/tlv"
+
+const (
+	IssuerScriptBeforeAC = 0x71
+	IssuerScriptAfterAC  = 0x72
+)
+
+// IssuerScript is an issuer script template (71 or 72) received in the
+// online response, holding the commands to be sent to the card.
+type IssuerScript struct {
+	Template   int
+	Identifier []byte
+	Commands   [][]byte
+}
+
+// DecodeIssuerScripts decodes the issuer script templates found in data,
+// ignoring any other data object.
+func DecodeIssuerScripts(data []byte) ([]*IssuerScript, error) {
+	list, err := tlv.DecodeList(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	scripts := make([]*IssuerScript, 0)
+
+	for _, n := range list {
+		if n.Tag != IssuerScriptBeforeAC && n.Tag != IssuerScriptAfterAC {
+			continue
+		}
+
+		template, err := tlv.DecodeList(n.Value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		script := &IssuerScript{
+			Template: n.Tag,
+			Commands: template.FindAll(0x86),
+		}
+
+		script.Identifier, _ = template.Find(0x9F18)
+
+		scripts = append(scripts, script)
+	}
+
+	return scripts, nil
+}
+
+// result encodes the script result (9F5B entry) for the given outcome, where
+// failed is the 1-based index of the failing command or 0.
+func (s *IssuerScript) result(failed int) []byte {
+	result := make([]byte, 5)
+	result[0] = 0x20
+
+	if failed > 0 {
+		result[0] = 0x10
+
+		if failed < 0x0F {
+			result[0] |= byte(failed)
+		} else {
+			result[0] |= 0x0F
+		}
+	}
+
+	if len(s.Identifier) == 4 {
+		copy(result[1:], s.Identifier)
+	}
+
+	return result
+}
@@ -0,0 +1,51 @@
+package emv
+
+// kernelElement returns the value of a data element kept by the kernel during
+// the transaction, for a DOL entry of the given length.
+type kernelElement func(c *Context, tx *Transaction, length int) ([]byte, error)
+
+// kernelElements are the data elements from the kernel state sent in DOL
+// related data.
+var kernelElements = map[int]kernelElement{
+	0x8A: func(c *Context, tx *Transaction, length int) ([]byte, error) {
+		return c.authorisationResponseCode, nil
+	},
+	0x91: func(c *Context, tx *Transaction, length int) ([]byte, error) {
+		return c.issuerAuthenticationData, nil
+	},
+	0x95: func(c *Context, tx *Transaction, length int) ([]byte, error) {
+		return c.tvr.Bytes(), nil
+	},
+	0x9B: func(c *Context, tx *Transaction, length int) ([]byte, error) {
+		return c.tsi.Bytes(), nil
+	},
+	0x9F34: func(c *Context, tx *Transaction, length int) ([]byte, error) {
+		return c.cvr.Bytes(), nil
+	},
+	0x9F37: func(c *Context, tx *Transaction, length int) ([]byte, error) {
+		if len(c.unpredictableNumber) != length {
+			number, err := c.generateUnpredictableNumber(length)
+
+			if err != nil {
+				return nil, err
+			}
+
+			c.unpredictableNumber = number
+		}
+
+		return c.unpredictableNumber, nil
+	},
+	0x9F45: func(c *Context, tx *Transaction, length int) ([]byte, error) {
+		return c.dataAuthenticationCode, nil
+	},
+	0x9F4C: func(c *Context, tx *Transaction, length int) ([]byte, error) {
+		return c.iccDynamicNumber, nil
+	},
+}
+
+func init() {
+	// Registered here as the TC Hash Value builds DOL related data itself
+	kernelElements[0x98] = func(c *Context, tx *Transaction, length int) ([]byte, error) {
+		return c.tcHashValue(tx)
+	}
+}
@@ -0,0 +1,11 @@
+package emv
+
+// OnlineResponse holds the data returned by the issuer in the authorization
+// response that is relevant to the card.
+type OnlineResponse struct {
+	AuthorisationResponseCode []byte `tlv:"8A"`
+	IssuerAuthenticationData  []byte `tlv:"91"`
+
+	// Issuer script templates 71 and 72, see DecodeIssuerScripts
+	IssuerScripts []*IssuerScript
+}
@@ -24,6 +24,22 @@ func (pk *PublicKey) Decrypt(data []byte) ([]byte, error) {
 	return result.Bytes(), nil
 }
 
+// Encrypt applies the public key to data, keeping the leading zeroes so the
+// result always has the modulus length.
+func (pk *PublicKey) Encrypt(data []byte) ([]byte, error) {
+	result, err := pk.Decrypt(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	size := len(pk.Modulus())
+	padded := make([]byte, size)
+	copy(padded[size-len(result):], result)
+
+	return padded, nil
+}
+
 func (pk *PublicKey) Modulus() []byte {
 	return pk.modulus.Bytes()
 }
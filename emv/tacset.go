@@ -1,7 +1,7 @@
 package emv
 
 type TacSet struct {
-	Default uint64
-	Denial  uint64
-	Online  uint64
+	Default TVR
+	Denial  TVR
+	Online  TVR
 }
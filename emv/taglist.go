@@ -15,7 +15,7 @@ func (tlPointer *TagList) DecodeTlv(data []byte) error {
 		tag, tagLength, err := tlv.DecodeTag(data[i:])
 
 		if err != nil {
-			return err
+			return tlv.ShiftError(err, i)
 		}
 
 		i += tagLength
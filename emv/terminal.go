@@ -1,7 +1,94 @@
 package emv
 
+import (
+	"github.com/greenboxal/emv-kernel/tlv"
+	"strings"
+)
+
 type Terminal struct {
-	Type         int
-	CountryCode  []byte
-	CurrencyCode int
+	Type                   int                            `tlv:"9F35"`
+	Capabilities           TerminalCapabilities           `tlv:"9F33"`
+	AdditionalCapabilities AdditionalTerminalCapabilities `tlv:"9F40"`
+	CountryCode            int                            `tlv:"9F1A,n,len=2"`
+	CurrencyCode           int                            `tlv:"5F2A,n,len=2"`
+	CurrencyExponent       int                            `tlv:"5F36,n,len=1"`
+
+	IfdSerialNumber        string `tlv:"9F1E,an,len=8,omitempty"`
+	TerminalIdentification string `tlv:"9F1C,an,len=8,omitempty"`
+	AcquirerIdentifier     string `tlv:"9F01,n,len=6,omitempty"`
+
+	MerchantCategoryCode    int    `tlv:"9F15,n,len=2,omitempty"`
+	MerchantIdentifier      string `tlv:"9F16,ans,len=15,omitempty"`
+	MerchantNameAndLocation string `tlv:"9F4E,ans,omitempty"`
+
+	// Languages supported by the terminal, as ISO 639-1 codes in order of
+	// preference
+	Languages []string
+
+	DefaultDdol DataObjectList
+	DefaultTdol DataObjectList
+
+	// Other terminal data elements, sent as is
+	Other tlv.Tlv `tlv:"other"`
+}
+
+// selectLanguage returns the first language of the card preference (5F2D)
+// supported by the terminal, or the terminal preferred language.
+func (t *Terminal) selectLanguage(preference string) string {
+	for i := 0; i+2 <= len(preference); i += 2 {
+		for _, language := range t.Languages {
+			if strings.EqualFold(preference[i:i+2], language) {
+				return language
+			}
+		}
+	}
+
+	if len(t.Languages) > 0 {
+		return t.Languages[0]
+	}
+
+	return ""
+}
+
+// Data implements DataSource with the terminal configuration.
+func (t *Terminal) Data(tag int) ([]byte, bool, error) {
+	data := make(tlv.Tlv)
+
+	err := data.Marshal(t)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	value, found := data[tag]
+
+	return value, found, nil
+}
+
+// isOfflineOnly reports whether the terminal type (9F35) is offline only.
+func (t *Terminal) isOfflineOnly() bool {
+	switch t.Type & 0x0F {
+	case 3, 6:
+		return true
+	}
+
+	return false
+}
+
+func (t *Terminal) isAtm() bool {
+	switch t.Type {
+	case 0x14, 0x15, 0x16:
+		return true
+	}
+
+	return false
+}
+
+func (t *Terminal) isUnattended() bool {
+	switch t.Type & 0x0F {
+	case 4, 5, 6:
+		return true
+	}
+
+	return false
 }
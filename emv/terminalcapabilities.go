@@ -0,0 +1,69 @@
+package emv
+
+// TerminalCapabilities holds the Terminal Capabilities (9F33).
+type TerminalCapabilities uint64
+
+const (
+	CapManualKeyEntry TerminalCapabilities = 1 << 23
+	CapMagneticStripe TerminalCapabilities = 1 << 22
+	CapIcWithContacts TerminalCapabilities = 1 << 21
+
+	CapPlaintextPin         TerminalCapabilities = 1 << 15
+	CapEncipheredOnlinePin  TerminalCapabilities = 1 << 14
+	CapSignature            TerminalCapabilities = 1 << 13
+	CapEncipheredOfflinePin TerminalCapabilities = 1 << 12
+	CapNoCvm                TerminalCapabilities = 1 << 11
+
+	CapSda         TerminalCapabilities = 1 << 7
+	CapDda         TerminalCapabilities = 1 << 6
+	CapCardCapture TerminalCapabilities = 1 << 5
+	CapCda         TerminalCapabilities = 1 << 3
+)
+
+var terminalCapabilitiesNames = []bitName{
+	{uint64(CapManualKeyEntry), "Manual key entry"},
+	{uint64(CapMagneticStripe), "Magnetic stripe"},
+	{uint64(CapIcWithContacts), "IC with contacts"},
+	{uint64(CapPlaintextPin), "Plaintext PIN for ICC verification"},
+	{uint64(CapEncipheredOnlinePin), "Enciphered PIN for online verification"},
+	{uint64(CapSignature), "Signature"},
+	{uint64(CapEncipheredOfflinePin), "Enciphered PIN for offline verification"},
+	{uint64(CapNoCvm), "No CVM required"},
+	{uint64(CapSda), "SDA"},
+	{uint64(CapDda), "DDA"},
+	{uint64(CapCardCapture), "Card capture"},
+	{uint64(CapCda), "CDA"},
+}
+
+func (c *TerminalCapabilities) Set(bits TerminalCapabilities) {
+	*c |= bits
+}
+
+// Has reports whether any of bits is set.
+func (c TerminalCapabilities) Has(bits TerminalCapabilities) bool {
+	return c&bits != 0
+}
+
+func (c TerminalCapabilities) Bytes() []byte {
+	return encodeBits(uint64(c), 3)
+}
+
+func (c TerminalCapabilities) EncodeTlv() ([]byte, error) {
+	return c.Bytes(), nil
+}
+
+func (c *TerminalCapabilities) DecodeTlv(data []byte) error {
+	value, err := decodeBits(data, 3)
+
+	if err != nil {
+		return err
+	}
+
+	*c = TerminalCapabilities(value)
+
+	return nil
+}
+
+func (c TerminalCapabilities) String() string {
+	return describeBits(uint64(c), terminalCapabilitiesNames)
+}
@@ -0,0 +1,63 @@
+package emv
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+type HexBytes []byte
+
+func (h HexBytes) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(h)), nil
+}
+
+func (h *HexBytes) UnmarshalText(text []byte) error {
+	data, err := hex.DecodeString(string(text))
+
+	if err != nil {
+		return err
+	}
+
+	*h = data
+
+	return nil
+}
+
+type Trace struct {
+	Reader    string          `json:"reader"`
+	Atr       HexBytes        `json:"atr"`
+	Random    HexBytes        `json:"random"`
+	Exchanges []TraceExchange `json:"exchanges"`
+
+	// Transaction the session processed, so replaying doesn't depend on
+	// the current date
+	Transaction *Transaction `json:"transaction,omitempty"`
+}
+
+type TraceExchange struct {
+	Timestamp time.Time `json:"timestamp"`
+	Command   HexBytes  `json:"command"`
+	Response  HexBytes  `json:"response"`
+	SW        HexBytes  `json:"sw"`
+}
+
+func LoadTrace(r io.Reader) (*Trace, error) {
+	trace := &Trace{}
+
+	err := json.NewDecoder(r).Decode(trace)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return trace, nil
+}
+
+func (t *Trace) Save(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(t)
+}
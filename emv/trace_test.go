@@ -0,0 +1,84 @@
+package emv
+
+import (
+	"bytes"
+	"crypto/rand"
+	"github.com/greenboxal/emv-kernel/simulator"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTraceRecordAndReplay(t *testing.T) {
+	profile, cm := newTestProfile(t, []byte{0x40, 0x00})
+	recorder := NewTraceRecorder(simulator.NewCard(profile), rand.Reader, "Virtual Reader")
+
+	ctx := NewContext(NewCard(recorder), &ContextConfig{Terminal: testTerminal, Random: recorder}, cm)
+	assert.Nil(t, ctx.Initialize())
+
+	_, err := ctx.SelectApplication(testAid)
+	assert.Nil(t, err)
+
+	ok, err := ctx.Authenticate()
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.False(t, ctx.Tvr().Has(TvrOfflineNotPerformed|TvrSdaFailed))
+	assert.True(t, ctx.Tsi().Has(TsiOdaPerformed))
+
+	tx := &Transaction{Amount: 1000, Date: time.Date(2026, 10, 17, 13, 45, 30, 0, time.UTC)}
+	recorder.RecordTransaction(tx)
+
+	result, err := ctx.GenerateCryptogram(tx)
+	assert.Nil(t, err)
+
+	buffer := &bytes.Buffer{}
+	assert.Nil(t, recorder.Trace().Save(buffer))
+
+	trace, err := LoadTrace(buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, "Virtual Reader", trace.Reader)
+	assert.Equal(t, recorder.Trace().Exchanges[0].Command, trace.Exchanges[0].Command)
+
+	replayer := NewTraceReplayer(trace)
+	replayed := NewContext(NewCard(replayer), &ContextConfig{Terminal: testTerminal, Random: replayer}, cm)
+	assert.Nil(t, replayed.Initialize())
+
+	_, err = replayed.SelectApplication(testAid)
+	assert.Nil(t, err)
+	assert.Equal(t, ctx.CardInformation.Pan, replayed.CardInformation.Pan)
+
+	ok, err = replayed.Authenticate()
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, ctx.Tvr(), replayed.Tvr())
+	assert.Equal(t, ctx.dataAuthenticationCode, replayed.dataAuthenticationCode)
+
+	recorded := replayer.Transaction()
+	assert.NotNil(t, recorded)
+	assert.True(t, tx.Date.Equal(recorded.Date))
+	assert.Equal(t, tx.Amount, recorded.Amount)
+
+	replayedResult, err := replayed.GenerateCryptogram(recorded)
+	assert.Nil(t, err)
+	assert.Equal(t, result.Cryptogram, replayedResult.Cryptogram)
+	assert.Nil(t, replayer.Close())
+}
+
+func TestTraceReplayDivergence(t *testing.T) {
+	profile, cm := newTestProfile(t, []byte{0x40, 0x00})
+	recorder := NewTraceRecorder(simulator.NewCard(profile), rand.Reader, "Virtual Reader")
+
+	ctx := NewContext(NewCard(recorder), &ContextConfig{Terminal: testTerminal, Random: recorder}, cm)
+	assert.Nil(t, ctx.Initialize())
+
+	_, err := ctx.SelectApplication(testAid)
+	assert.Nil(t, err)
+
+	replayer := NewTraceReplayer(recorder.Trace())
+	replayed := NewContext(NewCard(replayer), &ContextConfig{Terminal: testTerminal, Random: replayer}, cm)
+	assert.Nil(t, replayed.Initialize())
+
+	_, err = replayed.SelectApplication(testAid[:5])
+	assert.IsType(t, &TraceDivergenceError{}, err)
+	assert.NotNil(t, replayer.Close())
+}
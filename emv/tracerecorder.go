@@ -0,0 +1,84 @@
+package emv
+
+import (
+	"io"
+	"time"
+)
+
+// TraceRecorder wraps a transport and the kernel random source, capturing
+// every exchange so the session can be replayed later by a TraceReplayer.
+type TraceRecorder struct {
+	transport Transport
+	random    io.Reader
+	trace     *Trace
+}
+
+func NewTraceRecorder(transport Transport, random io.Reader, reader string) *TraceRecorder {
+	return &TraceRecorder{
+		transport: transport,
+		random:    random,
+		trace: &Trace{
+			Reader:    reader,
+			Random:    HexBytes{},
+			Exchanges: []TraceExchange{},
+		},
+	}
+}
+
+func (tr *TraceRecorder) Trace() *Trace {
+	return tr.trace
+}
+
+// RecordTransaction stores the transaction processed in the session.
+func (tr *TraceRecorder) RecordTransaction(tx *Transaction) {
+	recorded := *tx
+	tr.trace.Transaction = &recorded
+}
+
+func (tr *TraceRecorder) Transmit(command []byte) ([]byte, error) {
+	res, err := tr.transport.Transmit(command)
+
+	if err != nil {
+		return nil, err
+	}
+
+	exchange := TraceExchange{
+		Timestamp: time.Now(),
+		Command:   append(HexBytes{}, command...),
+	}
+
+	if len(res) >= 2 {
+		exchange.Response = append(HexBytes{}, res[:len(res)-2]...)
+		exchange.SW = append(HexBytes{}, res[len(res)-2:]...)
+	} else {
+		exchange.Response = append(HexBytes{}, res...)
+	}
+
+	tr.trace.Exchanges = append(tr.trace.Exchanges, exchange)
+
+	return res, nil
+}
+
+func (tr *TraceRecorder) Reset() ([]byte, error) {
+	atr, err := tr.transport.Reset()
+
+	if err != nil {
+		return nil, err
+	}
+
+	tr.trace.Atr = append(HexBytes{}, atr...)
+
+	return atr, nil
+}
+
+func (tr *TraceRecorder) Close() error {
+	return tr.transport.Close()
+}
+
+func (tr *TraceRecorder) Read(p []byte) (int, error) {
+	n, err := tr.random.Read(p)
+
+	tr.trace.Random = append(tr.trace.Random, p[:n]...)
+
+	return n, err
+}
@@ -0,0 +1,85 @@
+package emv
+
+import (
+	"bytes"
+	"fmt"
+)
+
+type TraceDivergenceError struct {
+	Index    int
+	Expected []byte
+	Actual   []byte
+}
+
+func (e *TraceDivergenceError) Error() string {
+	return fmt.Sprintf("trace diverged at exchange %d: expected %x, got %x", e.Index, e.Expected, e.Actual)
+}
+
+// TraceReplayer serves a recorded trace back to the kernel.
+//
+// It must also be used as the context random source so terminal generated
+// data matches the recorded session.
+type TraceReplayer struct {
+	trace  *Trace
+	next   int
+	random int
+}
+
+func NewTraceReplayer(trace *Trace) *TraceReplayer {
+	return &TraceReplayer{
+		trace: trace,
+	}
+}
+
+// Transaction returns the transaction recorded in the trace, or nil.
+func (tr *TraceReplayer) Transaction() *Transaction {
+	if tr.trace.Transaction == nil {
+		return nil
+	}
+
+	tx := *tr.trace.Transaction
+
+	return &tx
+}
+
+func (tr *TraceReplayer) Transmit(command []byte) ([]byte, error) {
+	if tr.next >= len(tr.trace.Exchanges) {
+		return nil, &TraceDivergenceError{Index: tr.next, Actual: command}
+	}
+
+	exchange := tr.trace.Exchanges[tr.next]
+
+	if !bytes.Equal(exchange.Command, command) {
+		return nil, &TraceDivergenceError{Index: tr.next, Expected: exchange.Command, Actual: command}
+	}
+
+	tr.next++
+
+	res := append([]byte{}, exchange.Response...)
+
+	return append(res, exchange.SW...), nil
+}
+
+func (tr *TraceReplayer) Reset() ([]byte, error) {
+	return tr.trace.Atr, nil
+}
+
+// Close fails if the kernel didn't consume the whole trace.
+func (tr *TraceReplayer) Close() error {
+	if tr.next != len(tr.trace.Exchanges) {
+		return fmt.Errorf("trace has %d unreplayed exchanges", len(tr.trace.Exchanges)-tr.next)
+	}
+
+	return nil
+}
+
+func (tr *TraceReplayer) Read(p []byte) (int, error) {
+	if tr.random+len(p) > len(tr.trace.Random) {
+		return 0, fmt.Errorf("trace diverged: random source exhausted")
+	}
+
+	n := copy(p, tr.trace.Random[tr.random:])
+	tr.random += n
+
+	return n, nil
+}
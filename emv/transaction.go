@@ -1,10 +1,48 @@
 package emv
 
-import "time"
+import (
+	"github.com/greenboxal/emv-kernel/tlv"
+	"time"
+)
+
+// Transaction types (9C), the first two digits of the ISO 8583 processing
+// code. They are decimal as 9C is encoded in the n format.
+const (
+	TransactionTypePurchase = 0
+	TransactionTypeCash     = 1
+	TransactionTypeCashback = 9
+	TransactionTypeRefund   = 20
+)
 
 type Transaction struct {
-	Type             int
-	Date             time.Time
-	Amount           int
-	AdditionalAmount int
+	Type             int       `tlv:"9C,n,len=1" json:"type"`
+	Date             time.Time `tlv:"9A" json:"date"`
+	Amount           int       `tlv:"9F02,n,len=6" json:"amount"`
+	AdditionalAmount int       `tlv:"9F03,n,len=6" json:"additionalAmount"`
+}
+
+// Data implements DataSource with the transaction data, including the
+// Transaction Time (9F21) taken from Date.
+func (tx *Transaction) Data(tag int) ([]byte, bool, error) {
+	if tx.Date.IsZero() && (tag == 0x9A || tag == 0x9F21) {
+		return nil, false, nil
+	}
+
+	if tag == 0x9F21 {
+		value, err := tlv.EncodeNumeric(tx.Date.Format("150405"))
+
+		return value, err == nil, err
+	}
+
+	data := make(tlv.Tlv)
+
+	err := data.Marshal(tx)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	value, found := data[tag]
+
+	return value, found, nil
 }
@@ -1,8 +1,14 @@
 package emv
 
 type TransactionResult struct {
-	Approved       bool
-	ShouldGoOnline bool
-	CryptogramType CryptogramType
-	Cryptogram     []byte
+	Approved          bool
+	ShouldGoOnline    bool
+	SignatureRequired bool
+	CryptogramType    CryptogramType
+	Cryptogram        []byte
+
+	CryptogramInformationData     uint
+	ApplicationTransactionCounter uint
+	IssuerApplicationData         []byte
+	IssuerScriptResults           []byte
 }
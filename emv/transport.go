@@ -0,0 +1,7 @@
+package emv
+
+type Transport interface {
+	Transmit(command []byte) ([]byte, error)
+	Reset() ([]byte, error)
+	Close() error
+}
@@ -0,0 +1,55 @@
+package emv
+
+// TSI holds the Transaction Status Information (9B).
+type TSI uint64
+
+const (
+	TsiOdaPerformed           TSI = 1 << 15
+	TsiCvmPerformed           TSI = 1 << 14
+	TsiCardRiskManagement     TSI = 1 << 13
+	TsiIssuerAuthentication   TSI = 1 << 12
+	TsiTerminalRiskManagement TSI = 1 << 11
+	TsiScriptProcessing       TSI = 1 << 10
+)
+
+var tsiNames = []bitName{
+	{uint64(TsiOdaPerformed), "Offline data authentication was performed"},
+	{uint64(TsiCvmPerformed), "Cardholder verification was performed"},
+	{uint64(TsiCardRiskManagement), "Card risk management was performed"},
+	{uint64(TsiIssuerAuthentication), "Issuer authentication was performed"},
+	{uint64(TsiTerminalRiskManagement), "Terminal risk management was performed"},
+	{uint64(TsiScriptProcessing), "Script processing was performed"},
+}
+
+func (t *TSI) Set(bits TSI) {
+	*t |= bits
+}
+
+// Has reports whether any of bits is set.
+func (t TSI) Has(bits TSI) bool {
+	return t&bits != 0
+}
+
+func (t TSI) Bytes() []byte {
+	return encodeBits(uint64(t), 2)
+}
+
+func (t TSI) EncodeTlv() ([]byte, error) {
+	return t.Bytes(), nil
+}
+
+func (t *TSI) DecodeTlv(data []byte) error {
+	value, err := decodeBits(data, 2)
+
+	if err != nil {
+		return err
+	}
+
+	*t = TSI(value)
+
+	return nil
+}
+
+func (t TSI) String() string {
+	return describeBits(uint64(t), tsiNames)
+}
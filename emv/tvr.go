@@ -0,0 +1,101 @@
+package emv
+
+// TVR holds the Terminal Verification Results (95).
+type TVR uint64
+
+const (
+	TvrOfflineNotPerformed TVR = 1 << 39
+	TvrSdaFailed           TVR = 1 << 38
+	TvrIccDataMissing      TVR = 1 << 37
+	TvrHotlist             TVR = 1 << 36
+	TvrDdaFailed           TVR = 1 << 35
+	TvrCdaFailed           TVR = 1 << 34
+	TvrSdaSelected         TVR = 1 << 33
+
+	TvrDifferentVersions  TVR = 1 << 31
+	TvrExpiredApplication TVR = 1 << 30
+	TvrNotYetEffective    TVR = 1 << 29
+	TvrNotProductAllowed  TVR = 1 << 28
+	TvrNewCard            TVR = 1 << 27
+
+	TvrCvmFailed        TVR = 1 << 23
+	TvrUnrecognizedCvm  TVR = 1 << 22
+	TvrPinTryLimit      TVR = 1 << 21
+	TvrNoPinpad         TVR = 1 << 20
+	TvrPinNotEntered    TVR = 1 << 19
+	TvrOnlinePinEntered TVR = 1 << 18
+
+	TvrFloorLimit        TVR = 1 << 15
+	TvrOfflineLowerLimit TVR = 1 << 14
+	TvrOfflineUpperLimit TVR = 1 << 13
+	TvrRandomOnline      TVR = 1 << 12
+	TvrForcedOnline      TVR = 1 << 11
+
+	TvrDefaultTdol          TVR = 1 << 7
+	TvrIssuerAuthFailed     TVR = 1 << 6
+	TvrScriptFailedBeforeAC TVR = 1 << 5
+	TvrScriptFailedAfterAC  TVR = 1 << 4
+)
+
+var tvrNames = []bitName{
+	{uint64(TvrOfflineNotPerformed), "Offline data authentication not performed"},
+	{uint64(TvrSdaFailed), "SDA failed"},
+	{uint64(TvrIccDataMissing), "ICC data missing"},
+	{uint64(TvrHotlist), "Card appears on terminal exception file"},
+	{uint64(TvrDdaFailed), "DDA failed"},
+	{uint64(TvrCdaFailed), "CDA failed"},
+	{uint64(TvrSdaSelected), "SDA selected"},
+	{uint64(TvrDifferentVersions), "Different application versions"},
+	{uint64(TvrExpiredApplication), "Expired application"},
+	{uint64(TvrNotYetEffective), "Application not yet effective"},
+	{uint64(TvrNotProductAllowed), "Requested service not allowed for card product"},
+	{uint64(TvrNewCard), "New card"},
+	{uint64(TvrCvmFailed), "Cardholder verification was not successful"},
+	{uint64(TvrUnrecognizedCvm), "Unrecognised CVM"},
+	{uint64(TvrPinTryLimit), "PIN Try Limit exceeded"},
+	{uint64(TvrNoPinpad), "PIN entry required and PIN pad not present or not working"},
+	{uint64(TvrPinNotEntered), "PIN entry required, PIN pad present, but PIN was not entered"},
+	{uint64(TvrOnlinePinEntered), "Online PIN entered"},
+	{uint64(TvrFloorLimit), "Transaction exceeds floor limit"},
+	{uint64(TvrOfflineLowerLimit), "Lower consecutive offline limit exceeded"},
+	{uint64(TvrOfflineUpperLimit), "Upper consecutive offline limit exceeded"},
+	{uint64(TvrRandomOnline), "Transaction selected randomly for online processing"},
+	{uint64(TvrForcedOnline), "Merchant forced transaction online"},
+	{uint64(TvrDefaultTdol), "Default TDOL used"},
+	{uint64(TvrIssuerAuthFailed), "Issuer authentication failed"},
+	{uint64(TvrScriptFailedBeforeAC), "Script processing failed before final GENERATE AC"},
+	{uint64(TvrScriptFailedAfterAC), "Script processing failed after final GENERATE AC"},
+}
+
+func (t *TVR) Set(bits TVR) {
+	*t |= bits
+}
+
+// Has reports whether any of bits is set.
+func (t TVR) Has(bits TVR) bool {
+	return t&bits != 0
+}
+
+func (t TVR) Bytes() []byte {
+	return encodeBits(uint64(t), 5)
+}
+
+func (t TVR) EncodeTlv() ([]byte, error) {
+	return t.Bytes(), nil
+}
+
+func (t *TVR) DecodeTlv(data []byte) error {
+	value, err := decodeBits(data, 5)
+
+	if err != nil {
+		return err
+	}
+
+	*t = TVR(value)
+
+	return nil
+}
+
+func (t TVR) String() string {
+	return describeBits(uint64(t), tvrNames)
+}
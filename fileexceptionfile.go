@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// fileExceptionFile reads the exception file from a text file.
+//
+// Each line holds a PAN, optionally followed by a comma and the PAN sequence
+// number. Lines starting with # are ignored.
+type fileExceptionFile struct {
+	Path string
+}
+
+func (fef *fileExceptionFile) Contains(pan string, sequenceNumber int) (bool, error) {
+	file, err := os.Open(fef.Path)
+
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(line, ",", 2)
+
+		if strings.TrimSpace(fields[0]) != pan {
+			continue
+		}
+
+		if len(fields) == 1 {
+			return true, nil
+		}
+
+		number, err := strconv.Atoi(strings.TrimSpace(fields[1]))
+
+		if err != nil {
+			return false, err
+		}
+
+		if number == sequenceNumber {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
@@ -1,11 +1,21 @@
 package main
 
 import (
+	"crypto/rand"
+	"flag"
 	"fmt"
 	"github.com/ebfe/scard"
 	"github.com/greenboxal/emv-kernel/emv"
+	"io"
+	"os"
 )
 
+var recordPath = flag.String("record", "", "record the APDU session into a trace file")
+var replayPath = flag.String("replay", "", "replay a trace file instead of using a reader")
+var dump = flag.Bool("dump", false, "print the data objects of every card response")
+var hotlistPath = flag.String("hotlist", "./hotlist.txt", "terminal exception file, one PAN per line")
+var terminalPath = flag.String("terminal", "./terminal.json", "terminal configuration file")
+
 var hints = []emv.ApplicationHint{
 	emv.ApplicationHint{
 		Name:    []byte{0xA0, 0x00, 0x00, 0x00, 0x04, 0x10, 0x10},
@@ -25,17 +35,17 @@ var hints = []emv.ApplicationHint{
 	},
 }
 
-func getCard() (*scard.Card, error) {
+func getCard() (*scard.Card, string, error) {
 	ctx, err := scard.EstablishContext()
 
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	readers, err := ctx.ListReaders()
 
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	fmt.Printf("Available readers:\n")
@@ -50,23 +60,115 @@ func getCard() (*scard.Card, error) {
 	}
 
 	if selected == -1 {
-		return nil, err
+		return nil, "", err
+	}
+
+	card, err := ctx.Connect(readers[selected], scard.ShareExclusive, scard.ProtocolAny)
+
+	return card, readers[selected], err
+}
+
+func getTransport() (emv.Transport, io.Reader, error) {
+	if *replayPath != "" {
+		file, err := os.Open(*replayPath)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		defer file.Close()
+
+		trace, err := emv.LoadTrace(file)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		replayer := emv.NewTraceReplayer(trace)
+
+		return replayer, replayer, nil
+	}
+
+	rawCard, reader, err := getCard()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transport := &scardTransport{rawCard}
+
+	if *recordPath != "" {
+		recorder := emv.NewTraceRecorder(transport, rand.Reader, reader)
+
+		return recorder, recorder, nil
+	}
+
+	return transport, rand.Reader, nil
+}
+
+func saveTrace(transport emv.Transport) {
+	recorder, ok := transport.(*emv.TraceRecorder)
+
+	if !ok {
+		return
+	}
+
+	file, err := os.Create(*recordPath)
+
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
 	}
 
-	return ctx.Connect(readers[selected], scard.ShareExclusive, scard.ProtocolAny)
+	defer file.Close()
+
+	err = recorder.Trace().Save(file)
+
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+	}
 }
 
 func main() {
-	rawCard, err := getCard()
+	flag.Parse()
+
+	terminal, err := loadTerminal(*terminalPath)
 
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		return
 	}
 
-	card := emv.NewCard(rawCard)
+	transport, random, err := getTransport()
+
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	defer saveTrace(transport)
+
+	cardTransport := transport
+
+	if *dump {
+		cardTransport = &dumpTransport{transport}
+	}
+
+	card := emv.NewCard(cardTransport)
+
+	defer func() {
+		err := card.Close()
+
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}()
 
-	processor := NewTransactionProcessor(card)
+	processor := NewTransactionProcessor(card, transport, &emv.ContextConfig{
+		Terminal:      terminal,
+		Random:        random,
+		ExceptionFile: &fileExceptionFile{*hotlistPath},
+	})
 
 	err = processor.Initialize()
 
@@ -0,0 +1,31 @@
+package main
+
+import "github.com/ebfe/scard"
+
+type scardTransport struct {
+	card *scard.Card
+}
+
+func (st *scardTransport) Transmit(command []byte) ([]byte, error) {
+	return st.card.Transmit(command)
+}
+
+func (st *scardTransport) Reset() ([]byte, error) {
+	err := st.card.Reconnect(scard.ShareExclusive, scard.ProtocolAny, scard.ResetCard)
+
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := st.card.Status()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return status.Atr, nil
+}
+
+func (st *scardTransport) Close() error {
+	return st.card.Disconnect(scard.LeaveCard)
+}
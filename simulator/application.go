@@ -0,0 +1,162 @@
+package simulator
+
+import (
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha1"
+	"github.com/greenboxal/emv-kernel/tlv"
+)
+
+type Application struct {
+	Aid                []byte
+	Label              string
+	Priority           int
+	LanguagePreference string
+	Pdol               []byte
+
+	Aip     []byte
+	Records []Record
+
+	// Data objects retrievable with GET DATA (eg. 9F36, 9F13, 9F17)
+	Data tlv.Tlv
+
+	IccKey *rsa.PrivateKey
+	PinKey *rsa.PrivateKey
+	AcKey  []byte
+
+	Atc           int
+	LastOnlineAtc int
+	ForceOnline   bool
+	Format1       bool
+	Blocked       bool
+}
+
+// pinEnciphermentKey returns the key used to decipher enciphered PIN blocks,
+// which is the ICC key unless the application has a dedicated one.
+func (a *Application) pinEnciphermentKey() *rsa.PrivateKey {
+	if a.PinKey != nil {
+		return a.PinKey
+	}
+
+	return a.IccKey
+}
+
+func (a *Application) Record(sfi, number int) (*Record, bool) {
+	for i := range a.Records {
+		r := &a.Records[i]
+
+		if r.Sfi == sfi && r.Number == number {
+			return r, true
+		}
+	}
+
+	return nil, false
+}
+
+func (a *Application) ApplicationFileLocator() []byte {
+	afl := make([]byte, 0, len(a.Records)*4)
+
+	for _, r := range a.Records {
+		sda := byte(0)
+
+		if r.Sda {
+			sda = 1
+		}
+
+		afl = append(afl, byte(r.Sfi<<3), byte(r.Number), byte(r.Number), sda)
+	}
+
+	return afl
+}
+
+// find looks up a data object in the application records.
+func (a *Application) find(tag int) ([]byte, bool) {
+	for _, r := range a.Records {
+		t, err := tlv.DecodeTlv(r.Data)
+
+		if err != nil {
+			continue
+		}
+
+		if value, found := t[tag]; found {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+// StaticData returns the data to be authenticated as read by the terminal:
+// the SDA records followed by the values listed in the SDA tag list (9F4A).
+func (a *Application) StaticData() []byte {
+	data := make([]byte, 0)
+	sdaTags := false
+
+	for _, r := range a.Records {
+		if r.Sda {
+			if r.Sfi <= 10 {
+				data = append(data, r.Data...)
+			} else {
+				data = append(data, r.Encode()...)
+			}
+		}
+
+		t, err := tlv.DecodeTlv(r.Data)
+
+		if err != nil {
+			continue
+		}
+
+		if tags, found := t[0x9F4A]; found && len(tags) == 1 && tags[0] == 0x82 {
+			sdaTags = true
+		}
+	}
+
+	if sdaTags {
+		data = append(data, a.Aip...)
+	}
+
+	return data
+}
+
+// Arpc computes the Authorisation Response Cryptogram the issuer returns
+// for an ARQC.
+//
+// The card expects it in the Issuer Authentication Data, followed by the ARC.
+func (a *Application) Arpc(arqc, arc []byte) []byte {
+	mac := hmac.New(sha1.New, a.AcKey)
+	mac.Write(arqc)
+	mac.Write(arc)
+
+	return mac.Sum(nil)[:8]
+}
+
+func (a *Application) computeCryptogram(cid byte, atc, data []byte) []byte {
+	mac := hmac.New(sha1.New, a.AcKey)
+	mac.Write([]byte{cid})
+	mac.Write(atc)
+	mac.Write(data)
+
+	return mac.Sum(nil)[:8]
+}
+
+func (a *Application) signDynamicData(dynamicData, terminalData []byte) []byte {
+	size := a.IccKey.Size()
+
+	sdad := make([]byte, 0, size)
+	sdad = append(sdad, 0x6A, 0x05, 0x01, byte(len(dynamicData)))
+	sdad = append(sdad, dynamicData...)
+
+	for len(sdad) < size-21 {
+		sdad = append(sdad, 0xBB)
+	}
+
+	hash := sha1.New()
+	hash.Write(sdad[1:])
+	hash.Write(terminalData)
+
+	sdad = hash.Sum(sdad)
+	sdad = append(sdad, 0xBC)
+
+	return sign(a.IccKey, sdad)
+}
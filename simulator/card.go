@@ -0,0 +1,553 @@
+package simulator
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"fmt"
+	"github.com/greenboxal/emv-kernel/tlv"
+)
+
+var defaultAtr = []byte{0x3B, 0x65, 0x00, 0x00, 0x80, 0x31, 0x80, 0x65, 0xB0}
+
+const (
+	stateIdle = iota
+	stateSelected
+	stateInitiated
+	stateOnline
+	stateCompleted
+)
+
+type Card struct {
+	profile *Profile
+
+	state    int
+	selected *Application
+	cursor   int
+	pinTries int
+	closed   bool
+
+	pdolData  []byte
+	cdolData  []byte
+	challenge []byte
+
+	arqc                []byte
+	issuerAuthenticated bool
+	issuerAuthFailed    bool
+}
+
+func NewCard(profile *Profile) *Card {
+	return &Card{
+		profile:  profile,
+		pinTries: profile.PinTryLimit,
+		cursor:   -1,
+	}
+}
+
+func (c *Card) Reset() ([]byte, error) {
+	if c.closed {
+		return nil, fmt.Errorf("card was removed")
+	}
+
+	c.state = stateIdle
+	c.selected = nil
+	c.cursor = -1
+
+	if c.profile.Atr != nil {
+		return c.profile.Atr, nil
+	}
+
+	return defaultAtr, nil
+}
+
+func (c *Card) Close() error {
+	c.closed = true
+
+	return nil
+}
+
+func (c *Card) Transmit(command []byte) ([]byte, error) {
+	if c.closed {
+		return nil, fmt.Errorf("card was removed")
+	}
+
+	apdu, ok := parseCommand(command)
+
+	if !ok {
+		return status(0x67, 0x00), nil
+	}
+
+	switch apdu.ins {
+	case 0xA4:
+		return c.selectFile(apdu), nil
+	case 0xB2:
+		return c.readRecord(apdu), nil
+	case 0xA8:
+		return c.getProcessingOptions(apdu), nil
+	case 0x20:
+		return c.verify(apdu), nil
+	case 0xCA:
+		return c.getData(apdu), nil
+	case 0x84:
+		return c.getChallenge(apdu), nil
+	case 0x88:
+		return c.internalAuthenticate(apdu), nil
+	case 0x82:
+		return c.externalAuthenticate(apdu), nil
+	case 0x1E, 0x24, 0xDA:
+		return c.issuerScriptCommand(apdu), nil
+	case 0xAE:
+		return c.generateAC(apdu), nil
+	}
+
+	return status(0x6D, 0x00), nil
+}
+
+func (c *Card) selectFile(apdu *command) []byte {
+	if apdu.p1 != 0x04 {
+		return status(0x6A, 0x86)
+	}
+
+	c.state = stateIdle
+	c.selected = nil
+
+	if c.profile.Pse && bytes.Equal(apdu.data, []byte("1PAY.SYS.DDF01")) {
+		c.cursor = -1
+
+		a5 := Object(0x88, []byte{byte(c.profile.PseSfi)})
+
+		fci := Object(0x84, apdu.data)
+		fci = append(fci, Object(0xA5, a5)...)
+
+		return respond(Object(0x6F, fci))
+	}
+
+	start := 0
+
+	if apdu.p2&0x02 != 0 {
+		start = c.cursor + 1
+	}
+
+	for i := start; i < len(c.profile.Applications); i++ {
+		app := c.profile.Applications[i]
+
+		if !bytes.HasPrefix(app.Aid, apdu.data) {
+			continue
+		}
+
+		c.cursor = i
+		c.selected = app
+		c.state = stateSelected
+
+		a5 := Object(0x50, []byte(app.Label))
+		a5 = append(a5, Object(0x87, []byte{byte(app.Priority)})...)
+
+		if app.LanguagePreference != "" {
+			a5 = append(a5, Object(0x5F2D, []byte(app.LanguagePreference))...)
+		}
+
+		if app.Pdol != nil {
+			a5 = append(a5, Object(0x9F38, app.Pdol)...)
+		}
+
+		fci := Object(0x84, app.Aid)
+		fci = append(fci, Object(0xA5, a5)...)
+
+		if app.Blocked {
+			return append(Object(0x6F, fci), 0x62, 0x83)
+		}
+
+		return respond(Object(0x6F, fci))
+	}
+
+	c.cursor = len(c.profile.Applications)
+
+	return status(0x6A, 0x82)
+}
+
+func (c *Card) readRecord(apdu *command) []byte {
+	sfi := int(apdu.p2 >> 3)
+	number := int(apdu.p1)
+
+	if apdu.p2&0x07 != 0x04 {
+		return status(0x6A, 0x86)
+	}
+
+	if c.selected == nil {
+		if !c.profile.Pse || sfi != c.profile.PseSfi {
+			return status(0x69, 0x85)
+		}
+
+		if number < 1 || number > len(c.profile.Applications) {
+			return status(0x6A, 0x83)
+		}
+
+		app := c.profile.Applications[number-1]
+
+		entry := Object(0x4F, app.Aid)
+		entry = append(entry, Object(0x50, []byte(app.Label))...)
+		entry = append(entry, Object(0x87, []byte{byte(app.Priority)})...)
+
+		return respond(Object(0x70, Object(0x61, entry)))
+	}
+
+	record, found := c.selected.Record(sfi, number)
+
+	if !found {
+		return status(0x6A, 0x83)
+	}
+
+	return respond(record.Encode())
+}
+
+func (c *Card) getProcessingOptions(apdu *command) []byte {
+	if c.state != stateSelected {
+		return status(0x69, 0x85)
+	}
+
+	t, err := tlv.DecodeTlv(apdu.data)
+
+	if err != nil {
+		return status(0x67, 0x00)
+	}
+
+	if _, found := t[0x83]; !found {
+		return status(0x67, 0x00)
+	}
+
+	app := c.selected
+	app.Atc++
+	c.state = stateInitiated
+	c.pdolData = t[0x83]
+	c.cdolData = nil
+	c.arqc = nil
+	c.issuerAuthenticated = false
+	c.issuerAuthFailed = false
+
+	afl := app.ApplicationFileLocator()
+
+	if app.Format1 {
+		return respond(Object(0x80, append(append([]byte{}, app.Aip...), afl...)))
+	}
+
+	body := Object(0x82, app.Aip)
+	body = append(body, Object(0x94, afl)...)
+
+	return respond(Object(0x77, body))
+}
+
+func (c *Card) verify(apdu *command) []byte {
+	if c.state != stateInitiated {
+		return status(0x69, 0x85)
+	}
+
+	if apdu.p2 != 0x80 && apdu.p2 != 0x88 {
+		return status(0x6A, 0x86)
+	}
+
+	if c.pinTries == 0 {
+		return status(0x69, 0x83)
+	}
+
+	block := apdu.data
+
+	if apdu.p2 == 0x88 {
+		var ok bool
+
+		block, ok = c.decipherPinBlock(apdu.data)
+
+		if !ok {
+			return status(0x69, 0x84)
+		}
+	}
+
+	pin, ok := decodePinBlock(block)
+
+	if !ok {
+		return status(0x6A, 0x80)
+	}
+
+	if pin != c.profile.Pin {
+		c.pinTries--
+
+		return status(0x63, 0xC0|byte(c.pinTries))
+	}
+
+	c.pinTries = c.profile.PinTryLimit
+
+	return status(0x90, 0x00)
+}
+
+func (c *Card) getChallenge(apdu *command) []byte {
+	challenge, err := c.dynamicNumber()
+
+	if err != nil {
+		return status(0x6F, 0x00)
+	}
+
+	c.challenge = challenge
+
+	return respond(challenge)
+}
+
+// decipherPinBlock recovers the PIN block from an enciphered PIN data,
+// checking it against the last challenge, which can only be used once.
+func (c *Card) decipherPinBlock(data []byte) ([]byte, bool) {
+	key := c.selected.pinEnciphermentKey()
+	challenge := c.challenge
+	c.challenge = nil
+
+	if key == nil || challenge == nil || len(data) != key.Size() {
+		return nil, false
+	}
+
+	plain := sign(key, data)
+
+	if plain[0] != 0x7F || !bytes.Equal(plain[9:17], challenge) {
+		return nil, false
+	}
+
+	return plain[1:9], true
+}
+
+func (c *Card) getData(apdu *command) []byte {
+	if c.selected == nil {
+		return status(0x69, 0x85)
+	}
+
+	tag := int(apdu.p1)<<8 | int(apdu.p2)
+
+	switch tag {
+	case 0x9F36:
+		return respond(Object(tag, encodeCounter(c.selected.Atc)))
+	case 0x9F13:
+		return respond(Object(tag, encodeCounter(c.selected.LastOnlineAtc)))
+	case 0x9F17:
+		return respond(Object(tag, []byte{byte(c.pinTries)}))
+	}
+
+	value, found := c.selected.Data[tag]
+
+	if !found {
+		return status(0x6A, 0x88)
+	}
+
+	return respond(Object(tag, value))
+}
+
+func (c *Card) internalAuthenticate(apdu *command) []byte {
+	if c.state != stateInitiated {
+		return status(0x69, 0x85)
+	}
+
+	if c.selected.IccKey == nil {
+		return status(0x6A, 0x81)
+	}
+
+	number, err := c.dynamicNumber()
+
+	if err != nil {
+		return status(0x6F, 0x00)
+	}
+
+	dynamicData := append([]byte{byte(len(number))}, number...)
+	sdad := c.selected.signDynamicData(dynamicData, apdu.data)
+
+	return respond(Object(0x80, sdad))
+}
+
+func (c *Card) externalAuthenticate(apdu *command) []byte {
+	if c.state != stateOnline || c.issuerAuthenticated || c.issuerAuthFailed {
+		return status(0x69, 0x85)
+	}
+
+	if !c.authenticateIssuer(apdu.data) {
+		return status(0x63, 0x00)
+	}
+
+	return status(0x90, 0x00)
+}
+
+// authenticateIssuer checks the Issuer Authentication Data (ARPC || ARC)
+// against the ARQC, a failure causes the transaction to be declined.
+func (c *Card) authenticateIssuer(data []byte) bool {
+	if len(data) != 10 || !bytes.Equal(data[:8], c.selected.Arpc(c.arqc, data[8:])) {
+		c.issuerAuthFailed = true
+		return false
+	}
+
+	c.issuerAuthenticated = true
+
+	return true
+}
+
+// issuerScriptCommand handles the commands sent in issuer scripts, secure
+// messaging isn't checked.
+func (c *Card) issuerScriptCommand(apdu *command) []byte {
+	if c.state != stateOnline && c.state != stateCompleted {
+		return status(0x69, 0x85)
+	}
+
+	switch apdu.ins {
+	case 0x1E:
+		c.selected.Blocked = true
+	case 0x24:
+		if apdu.p2 != 0x00 {
+			return status(0x6A, 0x86)
+		}
+
+		c.pinTries = c.profile.PinTryLimit
+	case 0xDA:
+		if c.selected.Data == nil {
+			c.selected.Data = make(tlv.Tlv)
+		}
+
+		c.selected.Data[int(apdu.p1)<<8|int(apdu.p2)] = apdu.data
+	}
+
+	return status(0x90, 0x00)
+}
+
+func (c *Card) generateAC(apdu *command) []byte {
+	if c.state != stateInitiated && c.state != stateOnline {
+		return status(0x69, 0x85)
+	}
+
+	app := c.selected
+	requested := apdu.p1 & 0xC0
+
+	if requested == 0xC0 {
+		return status(0x6A, 0x86)
+	}
+
+	cid := requested
+
+	if c.state == stateOnline {
+		if requested == 0x80 {
+			return status(0x69, 0x85)
+		}
+
+		cdol, _ := app.find(0x8D)
+
+		if iad := terminalData(cdol, apdu.data, 0x91); iad != nil && !c.issuerAuthenticated && !c.issuerAuthFailed {
+			c.authenticateIssuer(iad)
+		}
+
+		if c.issuerAuthFailed || app.Blocked {
+			cid = 0x00
+		}
+
+		c.state = stateCompleted
+	} else {
+		if requested == 0x40 && app.ForceOnline {
+			cid = 0x80
+		}
+
+		if cid == 0x80 {
+			c.state = stateOnline
+		} else {
+			c.state = stateCompleted
+		}
+	}
+
+	cdolTag := 0x8C
+
+	if len(c.cdolData) > 0 {
+		cdolTag = 0x8D
+	}
+
+	c.cdolData = append(c.cdolData, apdu.data...)
+
+	atc := encodeCounter(app.Atc)
+	ac := app.computeCryptogram(cid, atc, apdu.data)
+
+	if cid == 0x80 {
+		c.arqc = ac
+	}
+	iad := []byte{0x06, 0x01, 0x0A, 0x03, 0x00, 0x00, 0x00}
+
+	if apdu.p1&0x10 != 0 && cid != 0x00 && app.IccKey != nil {
+		cdol, _ := app.find(cdolTag)
+		number, err := c.dynamicNumber()
+
+		if err != nil {
+			return status(0x6F, 0x00)
+		}
+
+		body := Object(0x9F27, []byte{cid})
+		body = append(body, Object(0x9F36, atc)...)
+		body = append(body, Object(0x9F10, iad)...)
+
+		hashed := append([]byte{}, c.pdolData...)
+		hashed = append(hashed, c.cdolData...)
+		hashed = append(hashed, body...)
+		transactionHash := sha1.Sum(hashed)
+
+		dynamicData := append([]byte{byte(len(number))}, number...)
+		dynamicData = append(dynamicData, cid)
+		dynamicData = append(dynamicData, ac...)
+		dynamicData = append(dynamicData, transactionHash[:]...)
+
+		un := terminalData(cdol, apdu.data, 0x9F37)
+		body = append(body, Object(0x9F4B, app.signDynamicData(dynamicData, un))...)
+
+		return respond(Object(0x77, body))
+	}
+
+	if app.Format1 {
+		body := append([]byte{cid}, atc...)
+		body = append(body, ac...)
+		body = append(body, iad...)
+
+		return respond(Object(0x80, body))
+	}
+
+	body := Object(0x9F27, []byte{cid})
+	body = append(body, Object(0x9F36, atc)...)
+	body = append(body, Object(0x9F26, ac)...)
+	body = append(body, Object(0x9F10, iad)...)
+
+	return respond(Object(0x77, body))
+}
+
+func (c *Card) dynamicNumber() ([]byte, error) {
+	number := make([]byte, 8)
+
+	_, err := rand.Read(number)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return number, nil
+}
+
+func decodePinBlock(block []byte) (string, bool) {
+	if len(block) != 8 || block[0]>>4 != 0x2 {
+		return "", false
+	}
+
+	length := int(block[0] & 0x0F)
+
+	if length < 4 || length > 12 {
+		return "", false
+	}
+
+	pin := make([]byte, length)
+
+	for i := 0; i < length; i++ {
+		digit := block[1+i/2] >> uint(4*(1-i%2)) & 0x0F
+
+		if digit > 9 {
+			return "", false
+		}
+
+		pin[i] = '0' + digit
+	}
+
+	return string(pin), true
+}
+
+func encodeCounter(value int) []byte {
+	return []byte{byte(value >> 8), byte(value)}
+}
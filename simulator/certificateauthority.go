@@ -0,0 +1,76 @@
+package simulator
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+)
+
+type CertificateAuthority struct {
+	Rid   []byte
+	Index int
+	Key   *rsa.PrivateKey
+}
+
+func NewCertificateAuthority(rid []byte, index, bits int) (*CertificateAuthority, error) {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &CertificateAuthority{
+		Rid:   rid,
+		Index: index,
+		Key:   key,
+	}, nil
+}
+
+func (ca *CertificateAuthority) NewIssuer(bits int, identifier, expiry []byte) (*Issuer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+
+	if err != nil {
+		return nil, err
+	}
+
+	size := ca.Key.Size()
+	modulus, remainder := splitModulus(key.N.Bytes(), size-36)
+	exponent := exponentBytes(&key.PublicKey)
+
+	cert := make([]byte, 0, size)
+	cert = append(cert, 0x6A, 0x02)
+	cert = append(cert, padRight(identifier, 4)...)
+	cert = append(cert, expiry...)
+	cert = append(cert, 0x00, 0x00, 0x01, 0x01, 0x01)
+	cert = append(cert, byte(key.Size()), byte(len(exponent)))
+	cert = append(cert, modulus...)
+
+	hash := sha1.New()
+	hash.Write(cert[1:])
+	hash.Write(remainder)
+	hash.Write(exponent)
+
+	cert = hash.Sum(cert)
+	cert = append(cert, 0xBC)
+
+	return &Issuer{
+		Key:         key,
+		Certificate: sign(ca.Key, cert),
+		Remainder:   remainder,
+		Exponent:    exponent,
+	}, nil
+}
+
+func padRight(data []byte, size int) []byte {
+	result := make([]byte, size)
+
+	for i := range result {
+		if i < len(data) {
+			result[i] = data[i]
+		} else {
+			result[i] = 0xFF
+		}
+	}
+
+	return result
+}
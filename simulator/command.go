@@ -0,0 +1,51 @@
+package simulator
+
+type command struct {
+	cla  byte
+	ins  byte
+	p1   byte
+	p2   byte
+	data []byte
+	le   byte
+}
+
+func parseCommand(raw []byte) (*command, bool) {
+	if len(raw) < 4 {
+		return nil, false
+	}
+
+	c := &command{
+		cla: raw[0],
+		ins: raw[1],
+		p1:  raw[2],
+		p2:  raw[3],
+	}
+
+	switch {
+	case len(raw) == 4:
+	case len(raw) == 5:
+		c.le = raw[4]
+	default:
+		lc := int(raw[4])
+
+		if lc == 0 || len(raw) < 5+lc || len(raw) > 6+lc {
+			return nil, false
+		}
+
+		c.data = raw[5 : 5+lc]
+
+		if len(raw) == 6+lc {
+			c.le = raw[5+lc]
+		}
+	}
+
+	return c, true
+}
+
+func respond(data []byte) []byte {
+	return append(append([]byte{}, data...), 0x90, 0x00)
+}
+
+func status(sw1, sw2 byte) []byte {
+	return []byte{sw1, sw2}
+}
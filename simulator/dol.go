@@ -0,0 +1,39 @@
+package simulator
+
+import "github.com/greenboxal/emv-kernel/tlv"
+
+// terminalData extracts the value of tag from the DOL related data sent by
+// the terminal, the concatenation of the values the DOL asks for.
+func terminalData(dol, data []byte, tag int) []byte {
+	offset := 0
+
+	for i := 0; i < len(dol); {
+		current, tagLength, err := tlv.DecodeTag(dol[i:])
+
+		if err != nil {
+			return nil
+		}
+
+		i += tagLength
+
+		length, lengthLength, err := tlv.DecodeLength(dol[i:])
+
+		if err != nil {
+			return nil
+		}
+
+		i += lengthLength
+
+		if offset+int(length) > len(data) {
+			return nil
+		}
+
+		if current == tag {
+			return data[offset : offset+int(length)]
+		}
+
+		offset += int(length)
+	}
+
+	return nil
+}
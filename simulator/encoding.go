@@ -0,0 +1,22 @@
+package simulator
+
+import "github.com/greenboxal/emv-kernel/tlv"
+
+// Object encodes a single BER-TLV data object.
+func Object(tag int, value []byte) []byte {
+	data := tlv.EncodeTag(tag)
+	data = append(data, tlv.EncodeLength(uint64(len(value)))...)
+
+	return append(data, value...)
+}
+
+// Objects concatenates already encoded data objects, keeping their order.
+func Objects(objects ...[]byte) []byte {
+	data := make([]byte, 0)
+
+	for _, o := range objects {
+		data = append(data, o...)
+	}
+
+	return data
+}
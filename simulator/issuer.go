@@ -0,0 +1,63 @@
+package simulator
+
+import (
+	"crypto/rsa"
+	"crypto/sha1"
+)
+
+type Issuer struct {
+	Key         *rsa.PrivateKey
+	Certificate []byte
+	Remainder   []byte
+	Exponent    []byte
+}
+
+// SignStaticData builds the Signed Static Application Data (93) over the
+// static data to be authenticated.
+func (i *Issuer) SignStaticData(staticData, dac []byte) []byte {
+	size := i.Key.Size()
+
+	ssad := make([]byte, 0, size)
+	ssad = append(ssad, 0x6A, 0x03, 0x01)
+	ssad = append(ssad, dac...)
+
+	for len(ssad) < size-21 {
+		ssad = append(ssad, 0xBB)
+	}
+
+	hash := sha1.New()
+	hash.Write(ssad[1:])
+	hash.Write(staticData)
+
+	ssad = hash.Sum(ssad)
+	ssad = append(ssad, 0xBC)
+
+	return sign(i.Key, ssad)
+}
+
+// CertifyIccKey builds the ICC Public Key Certificate (9F46) and returns it
+// together with the remainder (9F48) and exponent (9F47).
+func (i *Issuer) CertifyIccKey(key *rsa.PublicKey, pan, expiry, staticData []byte) ([]byte, []byte, []byte) {
+	size := i.Key.Size()
+	modulus, remainder := splitModulus(key.N.Bytes(), size-42)
+	exponent := exponentBytes(key)
+
+	cert := make([]byte, 0, size)
+	cert = append(cert, 0x6A, 0x04)
+	cert = append(cert, padRight(pan, 10)...)
+	cert = append(cert, expiry...)
+	cert = append(cert, 0x00, 0x00, 0x01, 0x01, 0x01)
+	cert = append(cert, byte(key.Size()), byte(len(exponent)))
+	cert = append(cert, modulus...)
+
+	hash := sha1.New()
+	hash.Write(cert[1:])
+	hash.Write(remainder)
+	hash.Write(exponent)
+	hash.Write(staticData)
+
+	cert = hash.Sum(cert)
+	cert = append(cert, 0xBC)
+
+	return sign(i.Key, cert), remainder, exponent
+}
@@ -0,0 +1,10 @@
+package simulator
+
+type Profile struct {
+	Atr          []byte
+	Pse          bool
+	PseSfi       int
+	Pin          string
+	PinTryLimit  int
+	Applications []*Application
+}
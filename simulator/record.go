@@ -0,0 +1,12 @@
+package simulator
+
+type Record struct {
+	Sfi    int
+	Number int
+	Data   []byte
+	Sda    bool
+}
+
+func (r *Record) Encode() []byte {
+	return Object(0x70, r.Data)
+}
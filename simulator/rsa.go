@@ -0,0 +1,48 @@
+package simulator
+
+import (
+	"crypto/rsa"
+	"math/big"
+)
+
+func sign(key *rsa.PrivateKey, data []byte) []byte {
+	m := big.NewInt(0)
+	m.SetBytes(data)
+
+	result := big.NewInt(0)
+	result.Exp(m, key.D, key.N)
+
+	return leftPad(result.Bytes(), key.Size())
+}
+
+func exponentBytes(key *rsa.PublicKey) []byte {
+	return big.NewInt(int64(key.E)).Bytes()
+}
+
+func leftPad(data []byte, size int) []byte {
+	if len(data) >= size {
+		return data
+	}
+
+	result := make([]byte, size)
+	copy(result[size-len(data):], data)
+
+	return result
+}
+
+// splitModulus splits the modulus in the part that fits inside a certificate
+// (padded with BB) and the remainder.
+func splitModulus(modulus []byte, space int) ([]byte, []byte) {
+	if len(modulus) > space {
+		return modulus[:space], modulus[space:]
+	}
+
+	result := make([]byte, space)
+	copy(result, modulus)
+
+	for i := len(modulus); i < space; i++ {
+		result[i] = 0xBB
+	}
+
+	return result, []byte{}
+}
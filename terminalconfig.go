@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/greenboxal/emv-kernel/emv"
+	"os"
+)
+
+// loadTerminal reads the terminal configuration from a JSON file with the
+// emv.Terminal fields. The country and currency codes are required.
+func loadTerminal(path string) (emv.Terminal, error) {
+	terminal := emv.Terminal{}
+
+	file, err := os.Open(path)
+
+	if os.IsNotExist(err) {
+		return terminal, fmt.Errorf("terminal configuration %s not found", path)
+	}
+
+	if err != nil {
+		return terminal, err
+	}
+
+	defer file.Close()
+
+	err = json.NewDecoder(file).Decode(&terminal)
+
+	if err != nil {
+		return terminal, fmt.Errorf("invalid terminal configuration %s: %v", path, err)
+	}
+
+	if terminal.CountryCode == 0 {
+		return terminal, fmt.Errorf("terminal configuration %s has no CountryCode", path)
+	}
+
+	if terminal.CurrencyCode == 0 {
+		return terminal, fmt.Errorf("terminal configuration %s has no CurrencyCode", path)
+	}
+
+	return terminal, nil
+}
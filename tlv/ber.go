@@ -1,25 +1,89 @@
 package tlv
 
-import "fmt"
-
-// Taken from: https://github.com/cdevr/WapSNMP
+// Tag classes, from the two most significant bits of the first tag byte
+const (
+	ClassUniversal       = 0x00
+	ClassApplication     = 0x40
+	ClassContextSpecific = 0x80
+	ClassPrivate         = 0xC0
+)
+
+// MaxTagLength is the longest tag supported, as tags are held in an int.
+//
+// EMV tags take at most three bytes, longer BER tags fail with ErrTagTooLong.
+const MaxTagLength = 4
 
+// EncodeTag encodes a tag, which holds the tag bytes as a big endian
+// integer (eg. 0x9F02).
 func EncodeTag(tag int) []byte {
-	if (tag>>8)&0x1F == 0 {
-		return []byte{byte(tag)}
+	data := []byte{byte(tag)}
+
+	for tag >>= 8; tag != 0; tag >>= 8 {
+		data = append([]byte{byte(tag)}, data...)
 	}
 
-	return []byte{byte(tag >> 8), byte(tag & 0xff)}
+	return data
 }
 
+// DecodeTag returns the tag and the number of bytes it takes or an error.
+//
+// Tags longer than MaxTagLength fail with ErrTagTooLong, so callers can tell
+// them apart from malformed data.
 func DecodeTag(toparse []byte) (int, int, error) {
+	if len(toparse) == 0 {
+		return 0, 0, &DecodeError{Offset: 0, Err: ErrTruncated}
+	}
+
+	tag := int(toparse[0])
+
 	if toparse[0]&0x1F != 0x1F {
-		return int(toparse[0]), 1, nil
+		return tag, 1, nil
+	}
+
+	// Subsequent bytes have b8 set while more bytes follow
+	length := 1
+
+	for ; length < len(toparse) && toparse[length]&0x80 != 0; length++ {
+	}
+
+	if length == len(toparse) {
+		return 0, 0, &DecodeError{Offset: len(toparse), Err: ErrTruncated}
+	}
+
+	length++
+
+	if length > MaxTagLength {
+		return 0, 0, &DecodeError{Offset: 0, Err: ErrTagTooLong}
+	}
+
+	for i := 1; i < length; i++ {
+		tag = tag<<8 | int(toparse[i])
+	}
+
+	return tag, length, nil
+}
+
+// firstTagByte returns the first byte of an encoded tag.
+func firstTagByte(tag int) int {
+	for tag > 0xFF {
+		tag >>= 8
 	}
 
-	return (int(toparse[0]) << 8) | int(toparse[1]), 2, nil
+	return tag
 }
 
+// TagClass returns the class of tag, one of the Class constants.
+func TagClass(tag int) int {
+	return firstTagByte(tag) & 0xC0
+}
+
+// IsConstructed reports whether tag is a constructed data object.
+func IsConstructed(tag int) bool {
+	return firstTagByte(tag)&0x20 != 0
+}
+
+// Taken from: https://github.com/cdevr/WapSNMP
+
 // EncodeLength encodes an integer value as a BER compliant length value.
 func EncodeLength(length uint64) []byte {
 	// The first bit is used to indicate whether this is the final byte
@@ -54,8 +118,12 @@ func EncodeLength(length uint64) []byte {
 func DecodeLength(toparse []byte) (uint64, int, error) {
 	// If the first bit is zero, the rest of the first byte indicates the length. Values up to 127 are encoded this way (unless you're using indefinite length, but we don't support that)
 
+	if len(toparse) == 0 {
+		return 0, 0, &DecodeError{Offset: 0, Err: ErrTruncated}
+	}
+
 	if toparse[0] == 0x80 {
-		return 0, 0, fmt.Errorf("we don't support indefinite length encoding")
+		return 0, 0, &DecodeError{Offset: 0, Err: ErrIndefiniteLength}
 	}
 	if toparse[0]&0x80 == 0 {
 		return uint64(toparse[0]), 1, nil
@@ -64,14 +132,14 @@ func DecodeLength(toparse []byte) (uint64, int, error) {
 	// If the first bit is one, the rest of the first byte encodes the length of then encoded length. So read how many bytes are part of the length.
 	numOctets := int(toparse[0] & 0x7f)
 	if len(toparse) < 1+numOctets {
-		return 0, 0, fmt.Errorf("invalid length")
+		return 0, 0, &DecodeError{Offset: len(toparse), Err: ErrTruncated}
 	}
 
 	// Decode the specified number of bytes as a BER Integer encoded
 	// value.
 	val, err := DecodeUInt(toparse[1 : numOctets+1])
 	if err != nil {
-		return 0, 0, err
+		return 0, 0, ShiftError(err, 1)
 	}
 
 	return val, 1 + numOctets, nil
@@ -82,7 +150,7 @@ func DecodeLength(toparse []byte) (uint64, int, error) {
 // Will error out if it's longer than 64 bits.
 func DecodeInteger(toparse []byte) (int64, error) {
 	if len(toparse) > 8 {
-		return 0, fmt.Errorf("don't support more than 64 bits")
+		return 0, &DecodeError{Offset: 0, Err: ErrValueTooLong}
 	}
 	var val int64
 	for _, b := range toparse {
@@ -99,7 +167,7 @@ func DecodeInteger(toparse []byte) (int64, error) {
 // Will error out if it's longer than 64 bits.
 func DecodeUInt(toparse []byte) (uint64, error) {
 	if len(toparse) > 8 {
-		return 0, fmt.Errorf("don't support more than 64 bits")
+		return 0, &DecodeError{Offset: 0, Err: ErrValueTooLong}
 	}
 	var val uint64
 	for _, b := range toparse {
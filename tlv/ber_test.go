@@ -0,0 +1,82 @@
+package tlv
+
+import (
+	"encoding/hex"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTag(t *testing.T) {
+	for _, tag := range []int{0x82, 0x9F02, 0xDF8117} {
+		encoded := EncodeTag(tag)
+
+		decoded, length, err := DecodeTag(append(encoded, 0x01))
+		assert.Nil(t, err)
+		assert.Equal(t, tag, decoded)
+		assert.Equal(t, len(encoded), length)
+	}
+
+	_, _, err := DecodeTag(nil)
+	assert.NotNil(t, err)
+
+	_, _, err = DecodeTag([]byte{0xDF, 0x81})
+	assert.NotNil(t, err)
+
+	_, _, err = DecodeTag([]byte{0xDF, 0x81, 0x81, 0x81, 0x01})
+	assert.True(t, errors.Is(err, ErrTagTooLong))
+
+	_, _, err = DecodeTag([]byte{0xDF, 0x81, 0x81, 0x81})
+	assert.True(t, errors.Is(err, ErrTruncated))
+
+	assert.Equal(t, ClassContextSpecific, TagClass(0x9F02))
+	assert.Equal(t, ClassApplication, TagClass(0x5F20))
+	assert.Equal(t, ClassPrivate, TagClass(0xDF8117))
+	assert.Equal(t, ClassApplication, TagClass(0x70))
+
+	assert.True(t, IsConstructed(0x70))
+	assert.True(t, IsConstructed(0xBF0C))
+	assert.False(t, IsConstructed(0x9F02))
+	assert.False(t, IsConstructed(0xDF8117))
+}
+
+func TestConstructed(t *testing.T) {
+	data, err := hex.DecodeString("00" + "700d" + "5a0841111111111111119f0100" + "ff" + "df811701aa" + "0000")
+	assert.Nil(t, err)
+
+	list, err := DecodeTree(data)
+	assert.Nil(t, err)
+	assert.Len(t, list, 2)
+	assert.Len(t, list[0].Children, 2)
+	assert.Equal(t, 0x9F01, list[0].Children[1].Tag)
+	assert.Equal(t, 0xDF8117, list[1].Tag)
+
+	rebuilt := List{}
+	rebuilt.AppendList(0x70, list[0].Children)
+	rebuilt.Append(0xDF8117, []byte{0xAA})
+	assert.Equal(t, list.Encode(), rebuilt.Encode())
+
+	values, err := DecodeTlv(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0xAA}, values[0xDF8117])
+
+	_, err = DecodeTree([]byte{0x70, 0x02, 0x5A, 0x05})
+	assert.NotNil(t, err)
+
+	// Malformed templates only fail when their children are decoded
+	data = []byte{0x70, 0x02, 0x5A, 0x05, 0xBF, 0x50, 0x03, 0x88, 0x05, 0x01, 0x9F, 0x36, 0x02, 0x00, 0x41}
+
+	list, err = DecodeList(data)
+	assert.Nil(t, err)
+	assert.Len(t, list, 3)
+	assert.Nil(t, list[0].Children)
+	assert.Equal(t, data, list.Encode())
+
+	values, err = DecodeTlv(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x88, 0x05, 0x01}, values[0xBF50])
+	assert.Equal(t, []byte{0x00, 0x41}, values[0x9F36])
+
+	_, err = DecodeTree(data)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,70 @@
+package tlv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// The data ends inside a tag, length or value
+	ErrTruncated = errors.New("truncated data")
+	// A well formed tag longer than MaxTagLength bytes
+	ErrTagTooLong = fmt.Errorf("tag longer than %d bytes not supported", MaxTagLength)
+	// A length of 80, which BER-TLV in EMV doesn't allow
+	ErrIndefiniteLength = errors.New("indefinite length not supported")
+	// A length or integer longer than 64 bits
+	ErrValueTooLong = errors.New("value too long")
+	// A value not matching its format, eg. a non BCD digit in n
+	ErrInvalidFormat = errors.New("invalid format")
+)
+
+// DecodeError is returned by the decoders when the data is malformed.
+//
+// Offset is counted from the start of the value of the innermost tag in
+// Path, or from the start of the data when Path is empty.
+type DecodeError struct {
+	Offset int
+	Path   []int
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	if len(e.Path) == 0 {
+		return fmt.Sprintf("%s at offset %d", e.Err, e.Offset)
+	}
+
+	path := make([]string, len(e.Path))
+
+	for i, tag := range e.Path {
+		path[i] = fmt.Sprintf("%X", tag)
+	}
+
+	return fmt.Sprintf("%s at offset %d in %s", e.Err, e.Offset, strings.Join(path, "/"))
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ShiftError moves a DecodeError found in data starting at offset.
+func ShiftError(err error, offset int) error {
+	var de *DecodeError
+
+	if !errors.As(err, &de) {
+		return err
+	}
+
+	return &DecodeError{Offset: de.Offset + offset, Path: de.Path, Err: de.Err}
+}
+
+// NestError records that err was found inside the value of tag.
+func NestError(err error, tag int) error {
+	var de *DecodeError
+
+	if !errors.As(err, &de) {
+		return err
+	}
+
+	return &DecodeError{Offset: de.Offset, Path: append([]int{tag}, de.Path...), Err: de.Err}
+}
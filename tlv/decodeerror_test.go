@@ -0,0 +1,50 @@
+package tlv
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDecodeError(t *testing.T) {
+	var de *DecodeError
+
+	_, err := DecodeList([]byte{0x9F})
+	assert.True(t, errors.As(err, &de))
+	assert.Equal(t, ErrTruncated, de.Err)
+	assert.Equal(t, 1, de.Offset)
+
+	_, err = DecodeList([]byte{0x00, 0x5A})
+	assert.True(t, errors.As(err, &de))
+	assert.True(t, errors.Is(err, ErrTruncated))
+	assert.Equal(t, 2, de.Offset)
+
+	_, err = DecodeList([]byte{0x82, 0x80})
+	assert.True(t, errors.Is(err, ErrIndefiniteLength))
+
+	_, err = DecodeList([]byte{0xDF, 0x81, 0x81, 0x81, 0x01, 0x00})
+	assert.True(t, errors.Is(err, ErrTagTooLong))
+
+	_, err = DecodeList([]byte{0x82, 0x89, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09})
+	assert.True(t, errors.Is(err, ErrValueTooLong))
+
+	_, err = DecodeTree([]byte{0x70, 0x04, 0x5A, 0x01, 0x01, 0x9F})
+	assert.True(t, errors.As(err, &de))
+	assert.Equal(t, []int{0x70}, de.Path)
+	assert.Equal(t, 4, de.Offset)
+
+	_, err = DecodeTree([]byte{0x70, 0x06, 0x5A, 0x01, 0x01, 0xA5, 0x01, 0x88})
+	assert.True(t, errors.As(err, &de))
+	assert.Equal(t, []int{0x70, 0xA5}, de.Path)
+	assert.Equal(t, 1, de.Offset)
+	assert.Equal(t, "truncated data at offset 1 in 70/A5", err.Error())
+
+	values := Tlv{0x6F: {0xA5, 0x03, 0x88, 0x05, 0x01}}
+
+	fci, _, err := values.Tlv(0x6F)
+	assert.Nil(t, err)
+
+	_, _, err = fci.Tlv(0xA5)
+	assert.True(t, errors.As(err, &de))
+	assert.Equal(t, []int{0xA5, 0x88}, de.Path)
+}
@@ -0,0 +1,171 @@
+package tlv
+
+import "fmt"
+
+// elements lists the data elements of EMV Book 3 Annex A, plus the most
+// common payment system specific ones.
+var elements = []*Element{
+	{0x42, "Issuer Identification Number (IIN)", SourceIcc, FormatN, 3, 3, []int{0xBF0C}, false},
+	{0x4F, "Application Identifier (AID) - card", SourceIcc, FormatB, 5, 16, []int{0x61}, false},
+	{0x50, "Application Label", SourceIcc, FormatAns, 1, 16, []int{0x61, 0xA5}, false},
+	{0x57, "Track 2 Equivalent Data", SourceIcc, FormatB, 0, 19, []int{0x70, 0x77}, false},
+	{0x5A, "Application Primary Account Number (PAN)", SourceIcc, FormatCn, 0, 10, []int{0x70, 0x77}, false},
+	{0x5F20, "Cardholder Name", SourceIcc, FormatAns, 2, 26, []int{0x70, 0x77}, false},
+	{0x5F24, "Application Expiration Date", SourceIcc, FormatDate, 3, 3, []int{0x70, 0x77}, false},
+	{0x5F25, "Application Effective Date", SourceIcc, FormatDate, 3, 3, []int{0x70, 0x77}, false},
+	{0x5F28, "Issuer Country Code", SourceIcc, FormatN, 2, 2, []int{0x70, 0x77}, false},
+	{0x5F2A, "Transaction Currency Code", SourceTerminal, FormatN, 2, 2, nil, false},
+	{0x5F2D, "Language Preference", SourceIcc, FormatAn, 2, 8, []int{0xA5}, false},
+	{0x5F30, "Service Code", SourceIcc, FormatN, 2, 2, []int{0x70, 0x77}, false},
+	{0x5F34, "Application Primary Account Number (PAN) Sequence Number", SourceIcc, FormatN, 1, 1, []int{0x70, 0x77}, false},
+	{0x5F36, "Transaction Currency Exponent", SourceTerminal, FormatN, 1, 1, nil, false},
+	{0x5F50, "Issuer URL", SourceIcc, FormatAns, 0, 0, []int{0xBF0C}, false},
+	{0x5F53, "International Bank Account Number (IBAN)", SourceIcc, FormatB, 0, 34, []int{0xBF0C}, false},
+	{0x5F54, "Bank Identifier Code (BIC)", SourceIcc, FormatB, 8, 11, []int{0xBF0C}, false},
+	{0x5F55, "Issuer Country Code (alpha2 format)", SourceIcc, FormatA, 2, 2, []int{0xBF0C}, false},
+	{0x5F56, "Issuer Country Code (alpha3 format)", SourceIcc, FormatA, 3, 3, []int{0xBF0C}, false},
+	{0x5F57, "Account Type", SourceTerminal, FormatN, 1, 1, nil, false},
+	{0x61, "Application Template", SourceIcc, FormatB, 0, 252, []int{0x70}, false},
+	{0x6F, "File Control Information (FCI) Template", SourceIcc, FormatB, 0, 252, nil, false},
+	{0x70, "READ RECORD Response Message Template", SourceIcc, FormatB, 0, 252, nil, false},
+	{0x71, "Issuer Script Template 1", SourceIssuer, FormatB, 0, 0, nil, false},
+	{0x72, "Issuer Script Template 2", SourceIssuer, FormatB, 0, 0, nil, false},
+	{0x73, "Directory Discretionary Template", SourceIcc, FormatB, 0, 252, []int{0x61}, false},
+	{0x77, "Response Message Template Format 2", SourceIcc, FormatB, 0, 0, nil, false},
+	{0x80, "Response Message Template Format 1", SourceIcc, FormatB, 0, 0, nil, false},
+	{0x81, "Amount, Authorised (Binary)", SourceTerminal, FormatB, 4, 4, nil, false},
+	{0x82, "Application Interchange Profile", SourceIcc, FormatB, 2, 2, []int{0x77, 0x80}, false},
+	{0x83, "Command Template", SourceTerminal, FormatB, 0, 0, nil, false},
+	{0x84, "Dedicated File (DF) Name", SourceIcc, FormatB, 5, 16, []int{0x6F}, false},
+	{0x86, "Issuer Script Command", SourceIssuer, FormatB, 0, 261, []int{0x71, 0x72}, false},
+	{0x87, "Application Priority Indicator", SourceIcc, FormatB, 1, 1, []int{0x61, 0xA5}, false},
+	{0x88, "Short File Identifier (SFI)", SourceIcc, FormatB, 1, 1, []int{0xA5}, false},
+	{0x89, "Authorisation Code", SourceIssuer, FormatAns, 6, 6, nil, false},
+	{0x8A, "Authorisation Response Code", SourceIssuer, FormatAn, 2, 2, nil, false},
+	{0x8C, "Card Risk Management Data Object List 1 (CDOL1)", SourceIcc, FormatB, 0, 252, []int{0x70, 0x77}, false},
+	{0x8D, "Card Risk Management Data Object List 2 (CDOL2)", SourceIcc, FormatB, 0, 252, []int{0x70, 0x77}, false},
+	{0x8E, "Cardholder Verification Method (CVM) List", SourceIcc, FormatB, 10, 252, []int{0x70, 0x77}, false},
+	{0x8F, "Certification Authority Public Key Index", SourceIcc, FormatB, 1, 1, []int{0x70, 0x77}, false},
+	{0x90, "Issuer Public Key Certificate", SourceIcc, FormatB, 0, 0, []int{0x70, 0x77}, false},
+	{0x91, "Issuer Authentication Data", SourceIssuer, FormatB, 8, 16, nil, false},
+	{0x92, "Issuer Public Key Remainder", SourceIcc, FormatB, 0, 0, []int{0x70, 0x77}, false},
+	{0x93, "Signed Static Application Data", SourceIcc, FormatB, 0, 0, []int{0x70, 0x77}, false},
+	{0x94, "Application File Locator (AFL)", SourceIcc, FormatB, 0, 252, []int{0x77, 0x80}, false},
+	{0x95, "Terminal Verification Results", SourceTerminal, FormatB, 5, 5, nil, false},
+	{0x97, "Transaction Certificate Data Object List (TDOL)", SourceIcc, FormatB, 0, 252, []int{0x70, 0x77}, false},
+	{0x98, "Transaction Certificate (TC) Hash Value", SourceTerminal, FormatB, 20, 20, nil, false},
+	{0x99, "Transaction Personal Identification Number (PIN) Data", SourceTerminal, FormatB, 0, 0, nil, false},
+	{0x9A, "Transaction Date", SourceTerminal, FormatDate, 3, 3, nil, false},
+	{0x9B, "Transaction Status Information", SourceTerminal, FormatB, 2, 2, nil, false},
+	{0x9C, "Transaction Type", SourceTerminal, FormatN, 1, 1, nil, false},
+	{0x9D, "Directory Definition File (DDF) Name", SourceIcc, FormatB, 5, 16, []int{0x61}, false},
+	{0x9F01, "Acquirer Identifier", SourceTerminal, FormatN, 6, 6, nil, false},
+	{0x9F02, "Amount, Authorised (Numeric)", SourceTerminal, FormatN, 6, 6, nil, false},
+	{0x9F03, "Amount, Other (Numeric)", SourceTerminal, FormatN, 6, 6, nil, false},
+	{0x9F04, "Amount, Other (Binary)", SourceTerminal, FormatB, 4, 4, nil, false},
+	{0x9F05, "Application Discretionary Data", SourceIcc, FormatB, 1, 32, []int{0x70, 0x77}, false},
+	{0x9F06, "Application Identifier (AID) - terminal", SourceTerminal, FormatB, 5, 16, nil, false},
+	{0x9F07, "Application Usage Control", SourceIcc, FormatB, 2, 2, []int{0x70, 0x77}, false},
+	{0x9F08, "Application Version Number", SourceIcc, FormatB, 2, 2, []int{0x70, 0x77}, false},
+	{0x9F09, "Application Version Number", SourceTerminal, FormatB, 2, 2, nil, false},
+	{0x9F0A, "Application Selection Registered Proprietary Data", SourceIcc, FormatB, 0, 0, []int{0xBF0C}, false},
+	{0x9F0B, "Cardholder Name Extended", SourceIcc, FormatAns, 27, 45, []int{0x70, 0x77}, false},
+	{0x9F0D, "Issuer Action Code - Default", SourceIcc, FormatB, 5, 5, []int{0x70, 0x77}, false},
+	{0x9F0E, "Issuer Action Code - Denial", SourceIcc, FormatB, 5, 5, []int{0x70, 0x77}, false},
+	{0x9F0F, "Issuer Action Code - Online", SourceIcc, FormatB, 5, 5, []int{0x70, 0x77}, false},
+	{0x9F10, "Issuer Application Data", SourceIcc, FormatB, 0, 32, []int{0x77}, false},
+	{0x9F11, "Issuer Code Table Index", SourceIcc, FormatN, 1, 1, []int{0xA5}, false},
+	{0x9F12, "Application Preferred Name", SourceIcc, FormatAns, 1, 16, []int{0x61, 0xA5}, false},
+	{0x9F13, "Last Online Application Transaction Counter (ATC) Register", SourceIcc, FormatB, 2, 2, nil, false},
+	{0x9F14, "Lower Consecutive Offline Limit", SourceIcc, FormatB, 1, 1, []int{0x70, 0x77}, false},
+	{0x9F15, "Merchant Category Code", SourceTerminal, FormatN, 2, 2, nil, false},
+	{0x9F16, "Merchant Identifier", SourceTerminal, FormatAns, 15, 15, nil, false},
+	{0x9F17, "Personal Identification Number (PIN) Try Counter", SourceIcc, FormatB, 1, 1, nil, false},
+	{0x9F18, "Issuer Script Identifier", SourceIssuer, FormatB, 4, 4, []int{0x71, 0x72}, false},
+	{0x9F19, "Token Requestor ID", SourceIcc, FormatN, 6, 6, []int{0x70, 0x77}, false},
+	{0x9F1A, "Terminal Country Code", SourceTerminal, FormatN, 2, 2, nil, false},
+	{0x9F1B, "Terminal Floor Limit", SourceTerminal, FormatB, 4, 4, nil, false},
+	{0x9F1C, "Terminal Identification", SourceTerminal, FormatAn, 8, 8, nil, false},
+	{0x9F1D, "Terminal Risk Management Data", SourceTerminal, FormatB, 1, 8, nil, false},
+	{0x9F1E, "Interface Device (IFD) Serial Number", SourceTerminal, FormatAn, 8, 8, nil, false},
+	{0x9F1F, "Track 1 Discretionary Data", SourceIcc, FormatAns, 0, 0, []int{0x70, 0x77}, false},
+	{0x9F20, "Track 2 Discretionary Data", SourceIcc, FormatCn, 0, 0, []int{0x70, 0x77}, false},
+	{0x9F21, "Transaction Time", SourceTerminal, FormatTime, 3, 3, nil, false},
+	{0x9F22, "Certification Authority Public Key Index", SourceTerminal, FormatB, 1, 1, nil, false},
+	{0x9F23, "Upper Consecutive Offline Limit", SourceIcc, FormatB, 1, 1, []int{0x70, 0x77}, false},
+	{0x9F24, "Payment Account Reference (PAR)", SourceIcc, FormatAn, 29, 29, []int{0x70, 0x77}, false},
+	{0x9F25, "Last 4 Digits of PAN", SourceIcc, FormatN, 2, 2, []int{0x70, 0x77}, false},
+	{0x9F26, "Application Cryptogram", SourceIcc, FormatB, 8, 8, []int{0x77, 0x80}, false},
+	{0x9F27, "Cryptogram Information Data", SourceIcc, FormatB, 1, 1, []int{0x77, 0x80}, false},
+	{0x9F2D, "ICC PIN Encipherment Public Key Certificate", SourceIcc, FormatB, 0, 0, []int{0x70, 0x77}, false},
+	{0x9F2E, "ICC PIN Encipherment Public Key Exponent", SourceIcc, FormatB, 1, 3, []int{0x70, 0x77}, false},
+	{0x9F2F, "ICC PIN Encipherment Public Key Remainder", SourceIcc, FormatB, 0, 0, []int{0x70, 0x77}, false},
+	{0x9F32, "Issuer Public Key Exponent", SourceIcc, FormatB, 1, 3, []int{0x70, 0x77}, false},
+	{0x9F33, "Terminal Capabilities", SourceTerminal, FormatB, 3, 3, nil, false},
+	{0x9F34, "Cardholder Verification Method (CVM) Results", SourceTerminal, FormatB, 3, 3, nil, false},
+	{0x9F35, "Terminal Type", SourceTerminal, FormatN, 1, 1, nil, false},
+	{0x9F36, "Application Transaction Counter (ATC)", SourceIcc, FormatB, 2, 2, []int{0x77, 0x80}, false},
+	{0x9F37, "Unpredictable Number", SourceTerminal, FormatB, 4, 4, nil, false},
+	{0x9F38, "Processing Options Data Object List (PDOL)", SourceIcc, FormatB, 0, 0, []int{0xA5}, false},
+	{0x9F39, "Point-of-Service (POS) Entry Mode", SourceTerminal, FormatN, 1, 1, nil, false},
+	{0x9F3A, "Amount, Reference Currency", SourceTerminal, FormatB, 4, 4, nil, false},
+	{0x9F3B, "Application Reference Currency", SourceIcc, FormatN, 2, 8, []int{0x70, 0x77}, false},
+	{0x9F3C, "Transaction Reference Currency Code", SourceTerminal, FormatN, 2, 2, nil, false},
+	{0x9F3D, "Transaction Reference Currency Exponent", SourceTerminal, FormatN, 1, 1, nil, false},
+	{0x9F40, "Additional Terminal Capabilities", SourceTerminal, FormatB, 5, 5, nil, false},
+	{0x9F41, "Transaction Sequence Counter", SourceTerminal, FormatN, 2, 4, nil, false},
+	{0x9F42, "Application Currency Code", SourceIcc, FormatN, 2, 2, []int{0x70, 0x77}, false},
+	{0x9F43, "Application Reference Currency Exponent", SourceIcc, FormatN, 1, 4, []int{0x70, 0x77}, false},
+	{0x9F44, "Application Currency Exponent", SourceIcc, FormatN, 1, 1, []int{0x70, 0x77}, false},
+	{0x9F45, "Data Authentication Code", SourceIcc, FormatB, 2, 2, nil, false},
+	{0x9F46, "ICC Public Key Certificate", SourceIcc, FormatB, 0, 0, []int{0x70, 0x77}, false},
+	{0x9F47, "ICC Public Key Exponent", SourceIcc, FormatB, 1, 3, []int{0x70, 0x77}, false},
+	{0x9F48, "ICC Public Key Remainder", SourceIcc, FormatB, 0, 0, []int{0x70, 0x77}, false},
+	{0x9F49, "Dynamic Data Authentication Data Object List (DDOL)", SourceIcc, FormatB, 0, 252, []int{0x70, 0x77}, false},
+	{0x9F4A, "Static Data Authentication Tag List", SourceIcc, FormatB, 0, 0, []int{0x70, 0x77}, false},
+	{0x9F4B, "Signed Dynamic Application Data", SourceIcc, FormatB, 0, 0, []int{0x77, 0x80}, false},
+	{0x9F4C, "ICC Dynamic Number", SourceIcc, FormatB, 2, 8, nil, false},
+	{0x9F4D, "Log Entry", SourceIcc, FormatB, 2, 2, []int{0xBF0C, 0x73}, false},
+	{0x9F4E, "Merchant Name and Location", SourceTerminal, FormatAns, 0, 0, nil, false},
+	{0x9F4F, "Log Format", SourceIcc, FormatB, 0, 0, nil, false},
+	{0xA5, "File Control Information (FCI) Proprietary Template", SourceIcc, FormatB, 0, 0, []int{0x6F}, false},
+	{0xBF0C, "File Control Information (FCI) Issuer Discretionary Data", SourceIcc, FormatB, 0, 222, []int{0xA5}, false},
+
+	// Payment system specific
+	{0x9F51, "Application Currency Code (VSDC)", SourceIcc, FormatN, 2, 2, []int{0x70, 0x77}, true},
+	{0x9F52, "Application Default Action (ADA)", SourceIcc, FormatB, 2, 4, []int{0x70, 0x77}, true},
+	{0x9F53, "Transaction Category Code", SourceTerminal, FormatAn, 1, 1, nil, true},
+	{0x9F56, "Issuer Authentication Indicator", SourceIcc, FormatB, 1, 1, []int{0x70, 0x77}, true},
+	{0x9F5B, "Issuer Script Results", SourceTerminal, FormatB, 0, 0, nil, true},
+	{0x9F5D, "Available Offline Spending Amount", SourceIcc, FormatB, 6, 6, nil, true},
+	{0x9F66, "Terminal Transaction Qualifiers (TTQ)", SourceTerminal, FormatB, 4, 4, nil, true},
+	{0x9F6C, "Card Transaction Qualifiers (CTQ)", SourceIcc, FormatB, 2, 2, nil, true},
+	{0x9F6D, "Mag-stripe Application Version Number (Reader)", SourceTerminal, FormatB, 2, 2, nil, true},
+	{0x9F6E, "Form Factor Indicator / Third Party Data", SourceIcc, FormatB, 4, 32, []int{0x70, 0x77}, true},
+	{0x9F7C, "Customer Exclusive Data", SourceIcc, FormatB, 0, 32, []int{0x77}, true},
+}
+
+var dictionary = make(map[int]*Element)
+
+func init() {
+	for _, e := range elements {
+		dictionary[e.Tag] = e
+	}
+}
+
+// Lookup returns the dictionary entry of tag.
+func Lookup(tag int) (*Element, bool) {
+	e, found := dictionary[tag]
+
+	return e, found
+}
+
+// Name returns the name of tag, or its hexadecimal representation if it
+// isn't in the dictionary.
+func Name(tag int) string {
+	if e, found := dictionary[tag]; found {
+		return e.Name
+	}
+
+	return fmt.Sprintf("%X", tag)
+}
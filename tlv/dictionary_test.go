@@ -0,0 +1,54 @@
+package tlv
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDictionary(t *testing.T) {
+	e, found := Lookup(0x5F24)
+	assert.True(t, found)
+	assert.Equal(t, "Application Expiration Date", e.Name)
+	assert.Equal(t, SourceIcc, e.Source)
+	assert.Equal(t, FormatDate, e.Format)
+	assert.Equal(t, "n", e.Format.String())
+	assert.False(t, e.Constructed())
+
+	e, found = Lookup(0x70)
+	assert.True(t, found)
+	assert.True(t, e.Constructed())
+
+	_, found = Lookup(0x9F7F)
+	assert.False(t, found)
+	assert.Equal(t, "9F7F", Name(0x9F7F))
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		tag   int
+		value []byte
+		valid bool
+	}{
+		{0x5F24, []byte{0x49, 0x12, 0x31}, true},
+		{0x5F24, []byte{0x49, 0x1A, 0x31}, false},
+		{0x5F24, []byte{0x49, 0x12}, false},
+		{0x5A, []byte{0x47, 0x61, 0x73, 0x90, 0x01, 0x01, 0x01, 0x19}, true},
+		{0x5A, []byte{0x47, 0x61, 0x73, 0x90, 0x01, 0x01, 0x01, 0x1F}, true},
+		{0x5A, []byte{0x47, 0x61, 0x73, 0x90, 0x01, 0x01, 0xF1, 0x1F}, false},
+		{0x5F20, []byte("CARDHOLDER/TEST"), true},
+		{0x5F20, []byte{'A', 0x00}, false},
+		{0x8A, []byte("00"), true},
+		{0x8A, []byte("0-"), false},
+		{0x5F55, []byte("BR"), true},
+		{0x5F55, []byte("B1"), false},
+		{0x5F57, []byte{0x20}, true},
+		{0x5F57, []byte{0x2A}, false},
+		{0x9F7F, []byte{0xFF}, true},
+	}
+
+	for _, c := range cases {
+		err := Tlv{c.tag: c.value}.Validate()
+
+		assert.Equal(t, c.valid, err == nil, "%X %x", c.tag, c.value)
+	}
+}
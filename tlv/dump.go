@@ -0,0 +1,78 @@
+package tlv
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+var describers = make(map[int]func(value []byte) string)
+
+// RegisterDescriber registers a function describing the values of tag in dumps.
+//
+// It is used for values the dictionary format can't describe, such as bitmaps.
+func RegisterDescriber(tag int, describer func(value []byte) string) {
+	describers[tag] = describer
+}
+
+// Dump formats BER-TLV data as an indented tree, expanding constructed data
+// objects and annotating each tag with its name and decoded value.
+func Dump(data []byte) (string, error) {
+	list, err := DecodeTree(data)
+
+	if err != nil {
+		return "", err
+	}
+
+	buffer := &bytes.Buffer{}
+	dumpList(buffer, list, 0)
+
+	return buffer.String(), nil
+}
+
+func dumpList(buffer *bytes.Buffer, list List, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	for _, n := range list {
+		fmt.Fprintf(buffer, "%s%X %s", indent, n.Tag, Name(n.Tag))
+
+		if IsConstructed(n.Tag) {
+			fmt.Fprintf(buffer, "\n")
+			dumpList(buffer, n.Children, depth+1)
+			continue
+		}
+
+		fmt.Fprintf(buffer, ": %s\n", describe(n.Tag, n.Value))
+	}
+}
+
+// describe formats a primitive value according to its format.
+func describe(tag int, value []byte) string {
+	raw := strings.ToUpper(hex.EncodeToString(value))
+
+	if describer, found := describers[tag]; found {
+		return fmt.Sprintf("%s (%s)", raw, describer(value))
+	}
+
+	e, found := Lookup(tag)
+
+	if !found || e.Validate(value) != nil {
+		return raw
+	}
+
+	switch e.Format {
+	case FormatA, FormatAn, FormatAns:
+		return fmt.Sprintf("%q", string(value))
+	case FormatCn:
+		return strings.TrimRight(raw, "F")
+	case FormatDate:
+		date, _ := DecodeDate(value)
+
+		return date.Format("2006-01-02")
+	case FormatTime:
+		return fmt.Sprintf("%s:%s:%s", raw[0:2], raw[2:4], raw[4:6])
+	}
+
+	return raw
+}
@@ -0,0 +1,33 @@
+package tlv
+
+import (
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	data, err := hex.DecodeString("6f1b8407a0000000031010a510500b5649534120435245444954870101" + "00" + "70105a084761739001010119" + "5f2403491231" + "9f3501ff")
+	assert.Nil(t, err)
+
+	RegisterDescriber(0x9F35, func(value []byte) string {
+		return "described"
+	})
+	defer delete(describers, 0x9F35)
+
+	dump, err := Dump(data)
+	assert.Nil(t, err)
+	assert.Equal(t, `6F File Control Information (FCI) Template
+  84 Dedicated File (DF) Name: A0000000031010
+  A5 File Control Information (FCI) Proprietary Template
+    50 Application Label: "VISA CREDIT"
+    87 Application Priority Indicator: 01
+70 READ RECORD Response Message Template
+  5A Application Primary Account Number (PAN): 4761739001010119
+  5F24 Application Expiration Date: 2049-12-31
+9F35 Terminal Type: FF (described)
+`, dump)
+
+	_, err = Dump([]byte{0x5A, 0x08, 0x47})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,40 @@
+package tlv
+
+import "fmt"
+
+// Element describes an EMV data element.
+type Element struct {
+	Tag    int
+	Name   string
+	Source Source
+	Format Format
+
+	// Length bounds in bytes, a MaxLength of 0 means it isn't bounded
+	MinLength int
+	MaxLength int
+
+	// Templates the element is found in, if any
+	Templates []int
+
+	// Defined by a payment system instead of EMV Book 3 Annex A
+	Proprietary bool
+}
+
+// Constructed reports whether the element is a template holding other data
+// objects.
+func (e *Element) Constructed() bool {
+	return IsConstructed(e.Tag)
+}
+
+// Validate checks the length and format of a value of this element.
+func (e *Element) Validate(value []byte) error {
+	if len(value) < e.MinLength || e.MaxLength > 0 && len(value) > e.MaxLength {
+		return fmt.Errorf("invalid length %d for %X (%s)", len(value), e.Tag, e.Name)
+	}
+
+	if !e.Format.Check(value) {
+		return fmt.Errorf("invalid value for %X (%s), expected format %s", e.Tag, e.Name, e.Format)
+	}
+
+	return nil
+}
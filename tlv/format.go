@@ -0,0 +1,140 @@
+package tlv
+
+import "fmt"
+
+// Format is the EMV format of a data element value (EMV Book 3, section 4.3).
+type Format int
+
+const (
+	_ Format = iota
+
+	// Alphabetic (a-z, A-Z)
+	FormatA
+	// Alphanumeric
+	FormatAn
+	// Alphanumeric special, from the common character set
+	FormatAns
+	// Binary
+	FormatB
+	// Compressed numeric, left justified and padded with F
+	FormatCn
+	// Numeric BCD, right justified and padded with leading zeroes
+	FormatN
+	// Numeric date, YYMMDD
+	FormatDate
+	// Numeric time, HHMMSS
+	FormatTime
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatA:
+		return "a"
+	case FormatAn:
+		return "an"
+	case FormatAns:
+		return "ans"
+	case FormatB:
+		return "b"
+	case FormatCn:
+		return "cn"
+	case FormatN, FormatDate, FormatTime:
+		return "n"
+	}
+
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// Check reports whether value is well formed for the format.
+func (f Format) Check(value []byte) bool {
+	switch f {
+	case FormatA:
+		for _, c := range value {
+			if !isAlpha(c) {
+				return false
+			}
+		}
+	case FormatAn:
+		for _, c := range value {
+			if !isAlpha(c) && !isDigit(c) {
+				return false
+			}
+		}
+	case FormatAns:
+		for _, c := range value {
+			if c < 0x20 || c > 0x7E {
+				return false
+			}
+		}
+	case FormatCn:
+		padding := false
+
+		for _, c := range value {
+			for _, digit := range []byte{c >> 4, c & 0x0F} {
+				if digit == 0x0F {
+					padding = true
+				} else if padding || digit > 9 {
+					return false
+				}
+			}
+		}
+	case FormatN:
+		for _, c := range value {
+			if c>>4 > 9 || c&0x0F > 9 {
+				return false
+			}
+		}
+	case FormatDate:
+		_, err := DecodeDate(value)
+
+		return err == nil
+	case FormatTime:
+		if len(value) != 3 || !FormatN.Check(value) {
+			return false
+		}
+
+		return value[0] < 0x24 && value[1] < 0x60 && value[2] < 0x60
+	}
+
+	return true
+}
+
+// Numeric reports whether the format is n, including dates and times.
+func (f Format) Numeric() bool {
+	return f == FormatN || f == FormatDate || f == FormatTime
+}
+
+// Fit truncates or pads value to length bytes as required for DOL related
+// data (EMV Book 3, section 5.4).
+//
+// n values lose their leftmost bytes or get
+// leading zeroes, cn values are padded with F and other values lose their
+// rightmost bytes or get trailing zeroes.
+func (f Format) Fit(value []byte, length int) []byte {
+	result := make([]byte, length)
+
+	switch {
+	case f.Numeric() && len(value) >= length:
+		copy(result, value[len(value)-length:])
+	case f.Numeric():
+		copy(result[length-len(value):], value)
+	default:
+		n := copy(result, value)
+
+		if f == FormatCn {
+			for i := n; i < length; i++ {
+				result[i] = 0xFF
+			}
+		}
+	}
+
+	return result
+}
+
+func isAlpha(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
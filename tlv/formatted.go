@@ -0,0 +1,188 @@
+package tlv
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// formatted reports whether value is encoded by encodeFormatted rather
+// than as a binary value.
+func (o options) formatted(value interface{}) bool {
+	switch value.(type) {
+	case time.Time, *time.Time:
+		return true
+	}
+
+	return o.format != 0 || o.length != 0
+}
+
+func encodeFormatted(value interface{}, o options) ([]byte, error) {
+	var data []byte
+	var err error
+
+	switch v := value.(type) {
+	case time.Time:
+		data = EncodeDate(v)
+	case []byte:
+		data = v
+	case string:
+		data, err = o.encodeString(v)
+	case int:
+		data, err = o.encodeNumber(int64(v))
+	case int64:
+		data, err = o.encodeNumber(v)
+	case uint:
+		data, err = o.encodeNumber(int64(v))
+	case uint64:
+		data, err = o.encodeNumber(int64(v))
+	default:
+		return nil, fmt.Errorf("go type %T can't be encoded as %s", value, o.format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return o.pad(data)
+}
+
+func (o options) encodeString(v string) ([]byte, error) {
+	switch o.format {
+	case FormatN:
+		return EncodeNumeric(v)
+	case FormatCn:
+		return EncodeCompressedNumeric(v)
+	case FormatAn, FormatAns:
+		if !o.format.Check([]byte(v)) {
+			return nil, fmt.Errorf("invalid %s value %q", o.format, v)
+		}
+
+		return []byte(v), nil
+	}
+
+	if o.hex {
+		return hex.DecodeString(v)
+	}
+
+	return []byte(v), nil
+}
+
+func (o options) encodeNumber(v int64) ([]byte, error) {
+	if o.format == 0 {
+		return EncodeInteger(v), nil
+	}
+
+	if v < 0 {
+		return nil, fmt.Errorf("negative value %d can't be encoded as %s", v, o.format)
+	}
+
+	return o.encodeString(strconv.FormatInt(v, 10))
+}
+
+func decodeFormatted(data []byte, value interface{}, o options) error {
+	data = o.unpad(data)
+
+	switch v := value.(type) {
+	case *time.Time:
+		date, err := DecodeDate(data)
+
+		if err != nil {
+			return err
+		}
+
+		*v = date
+	case *[]byte:
+		*v = data
+	case *string:
+		s, err := o.decodeString(data)
+
+		if err != nil {
+			return err
+		}
+
+		*v = s
+	case *int:
+		n, err := o.decodeNumber(data)
+
+		if err != nil {
+			return err
+		}
+
+		*v = int(n)
+	case *int64:
+		n, err := o.decodeNumber(data)
+
+		if err != nil {
+			return err
+		}
+
+		*v = int64(n)
+	case *uint:
+		n, err := o.decodeNumber(data)
+
+		if err != nil {
+			return err
+		}
+
+		*v = uint(n)
+	case *uint64:
+		n, err := o.decodeNumber(data)
+
+		if err != nil {
+			return err
+		}
+
+		*v = n
+	default:
+		return fmt.Errorf("go type %T can't be decoded as %s", value, o.format)
+	}
+
+	return nil
+}
+
+func (o options) decodeString(data []byte) (string, error) {
+	switch o.format {
+	case FormatN:
+		return DecodeNumeric(data)
+	case FormatCn:
+		return DecodeCompressedNumeric(data)
+	case FormatAn, FormatAns:
+		for i := range data {
+			if !o.format.Check(data[i : i+1]) {
+				return "", &DecodeError{Offset: i, Err: ErrInvalidFormat}
+			}
+		}
+	}
+
+	if o.hex {
+		return hex.EncodeToString(data), nil
+	}
+
+	return string(data), nil
+}
+
+func (o options) decodeNumber(data []byte) (uint64, error) {
+	if o.format == 0 {
+		return DecodeUInt(data)
+	}
+
+	digits, err := o.decodeString(data)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if digits == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseUint(digits, 10, 64)
+
+	if err != nil {
+		return 0, &DecodeError{Offset: 0, Err: ErrInvalidFormat}
+	}
+
+	return n, nil
+}
@@ -0,0 +1,69 @@
+package tlv
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type formattedTest struct {
+	Amount   int       `tlv:"9F02,n,len=6"`
+	Currency int       `tlv:"5F2A,n,len=2"`
+	Date     time.Time `tlv:"9A"`
+	Pan      string    `tlv:"5A,cn"`
+	Merchant string    `tlv:"9F4E,ans,len=8"`
+	Code     string    `tlv:"8A,an,len=2,left"`
+	Sequence int       `tlv:"5F34,n"`
+	Category int       `tlv:"9F15,n,len=2,omitempty"`
+}
+
+func TestFormatted(t *testing.T) {
+	value := formattedTest{
+		Amount:   1234,
+		Currency: 986,
+		Date:     time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		Pan:      "476173900101011",
+		Merchant: "SHOP 1",
+		Code:     "Z",
+		Sequence: 1,
+	}
+
+	values := make(Tlv)
+	assert.Nil(t, values.Marshal(value))
+
+	assert.Equal(t, Tlv{
+		0x9F02: {0x00, 0x00, 0x00, 0x00, 0x12, 0x34},
+		0x5F2A: {0x09, 0x86},
+		0x9A:   {0x24, 0x02, 0x29},
+		0x5A:   {0x47, 0x61, 0x73, 0x90, 0x01, 0x01, 0x01, 0x1F},
+		0x9F4E: {'S', 'H', 'O', 'P', ' ', '1', 0x00, 0x00},
+		0x8A:   {0x00, 'Z'},
+		0x5F34: {0x01},
+	}, values)
+
+	decoded := formattedTest{}
+	assert.Nil(t, values.Unmarshal(&decoded))
+	assert.Equal(t, value, decoded)
+
+	values[0x9F02] = []byte{0x00, 0x00, 0x00, 0x00, 0x12, 0x3A}
+	values[0x9A] = []byte{0x99, 0x12, 0x31}
+
+	err := values.Unmarshal(&decoded)
+	assert.True(t, errors.Is(err, ErrInvalidFormat))
+
+	date, err := DecodeDate([]byte{0x99, 0x12, 0x31})
+	assert.Nil(t, err)
+	assert.Equal(t, 1999, date.Year())
+
+	_, err = DecodeDate([]byte{0x23, 0x02, 0x29})
+	assert.NotNil(t, err)
+
+	err = values.MarshalValueWithOptions(0x9F02, 1234567890123, []string{"n", "len=6"})
+	assert.NotNil(t, err)
+
+	value.Category = 5411
+	values = make(Tlv)
+	assert.Nil(t, values.Marshal(value))
+	assert.Equal(t, []byte{0x54, 0x11}, values[0x9F15])
+}
@@ -0,0 +1,60 @@
+package tlv
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzDecodeList(f *testing.F) {
+	f.Add([]byte{0x6F, 0x0A, 0x84, 0x02, 0xA0, 0x00, 0xA5, 0x04, 0x50, 0x02, 0x41, 0x42})
+	f.Add([]byte{0x00, 0xDF, 0x81, 0x17, 0x01, 0xAA, 0xFF})
+	f.Add([]byte{0x70, 0x81, 0x03, 0x5A, 0x01, 0x01})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		list, err := DecodeList(data)
+
+		if err != nil {
+			return
+		}
+
+		// Encoding drops the padding only, so decoding again is stable
+		again, err := DecodeList(list.Encode())
+
+		if err != nil {
+			t.Fatalf("encoded list doesn't decode: %s", err)
+		}
+
+		if !bytes.Equal(list.Encode(), again.Encode()) {
+			t.Fatalf("encoded list changed")
+		}
+	})
+}
+
+func FuzzDecodeTlv(f *testing.F) {
+	f.Add([]byte{0x9F, 0x02, 0x06, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00})
+	f.Add([]byte{0x77, 0x05, 0x82, 0x02, 0x19, 0x80, 0x94})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		values, err := DecodeTlv(data)
+
+		if err != nil {
+			return
+		}
+
+		for tag := range values {
+			values.Tlv(tag)
+			values.Uint(tag)
+		}
+
+		values.Validate()
+	})
+}
+
+func FuzzDump(f *testing.F) {
+	f.Add([]byte{0x70, 0x08, 0x5F, 0x24, 0x03, 0x25, 0x12, 0x31, 0x95, 0x00})
+	f.Add([]byte{0x9F, 0x33, 0x03, 0xE0, 0xF8, 0xC8, 0x5F, 0x20, 0x02, 0x41, 0x42})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Dump(data)
+	})
+}
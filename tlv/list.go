@@ -0,0 +1,197 @@
+package tlv
+
+// Node is a data object of a List.
+type Node struct {
+	Tag   int
+	Value []byte
+
+	// Data objects inside a constructed data object, see DecodeTree
+	Children List
+
+	// Tag and length as found in the decoded data, so non minimal lengths
+	// are encoded back the same way while Tag and Value are unchanged
+	header []byte
+}
+
+// List is an ordered sequence of data objects.
+//
+// Unlike Tlv it keeps the order of the data objects and repeated tags, and
+// encodes back to the same bytes it was decoded from (except for padding
+// between data objects).
+type List []Node
+
+// DecodeList decodes the data objects at the top level of data, skipping the
+// 00 and FF padding allowed between data objects. Malformed data is reported
+// as a *DecodeError.
+//
+// Constructed values are left encoded, so malformed or proprietary templates
+// inside them don't fail the decoding. The values are copied, so the list
+// doesn't alias data.
+func DecodeList(data []byte) (List, error) {
+	return decodeList(data, false)
+}
+
+// DecodeTree decodes data like DecodeList, also decoding the Children of every
+// constructed data object. Malformed children are reported with their path.
+func DecodeTree(data []byte) (List, error) {
+	return decodeList(data, true)
+}
+
+func decodeList(data []byte, tree bool) (List, error) {
+	list := make(List, 0)
+
+	for i := 0; i < len(data); {
+		if data[i] == 0x00 || data[i] == 0xFF {
+			i++
+			continue
+		}
+
+		start := i
+
+		tag, tagLength, err := DecodeTag(data[i:])
+
+		if err != nil {
+			return nil, ShiftError(err, i)
+		}
+
+		i += tagLength
+
+		length, lengthLength, err := DecodeLength(data[i:])
+
+		if err != nil {
+			return nil, ShiftError(err, i)
+		}
+
+		i += lengthLength
+
+		if uint64(len(data)-i) < length {
+			return nil, &DecodeError{Offset: len(data) - i, Path: []int{tag}, Err: ErrTruncated}
+		}
+
+		n := Node{
+			Tag:    tag,
+			Value:  append([]byte{}, data[i:i+int(length)]...),
+			header: append([]byte{}, data[start:i]...),
+		}
+
+		if tree && IsConstructed(tag) {
+			n.Children, err = DecodeTree(n.Value)
+
+			if err != nil {
+				return nil, NestError(err, tag)
+			}
+		}
+
+		list = append(list, n)
+
+		i += int(length)
+	}
+
+	return list, nil
+}
+
+func (l List) Encode() []byte {
+	data := make([]byte, 0)
+
+	for _, n := range l {
+		data = append(data, n.Encode()...)
+	}
+
+	return data
+}
+
+func (l *List) Append(tag int, value []byte) {
+	*l = append(*l, Node{Tag: tag, Value: value})
+}
+
+// AppendList appends a constructed data object holding children.
+func (l *List) AppendList(tag int, children List) {
+	*l = append(*l, Node{Tag: tag, Value: children.Encode(), Children: children})
+}
+
+// Find returns the value of the first data object with tag.
+func (l List) Find(tag int) ([]byte, bool) {
+	for _, n := range l {
+		if n.Tag == tag {
+			return n.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// FindAll returns the values of every data object with tag, in order.
+func (l List) FindAll(tag int) [][]byte {
+	result := make([][]byte, 0)
+
+	for _, n := range l {
+		if n.Tag == tag {
+			result = append(result, n.Value)
+		}
+	}
+
+	return result
+}
+
+// Without returns the list without the data objects with tag.
+func (l List) Without(tag int) List {
+	result := make(List, 0, len(l))
+
+	for _, n := range l {
+		if n.Tag != tag {
+			result = append(result, n)
+		}
+	}
+
+	return result
+}
+
+// Map returns a Tlv view of the list, where the first data object wins when
+// a tag is repeated.
+func (l List) Map() Tlv {
+	t := make(Tlv)
+
+	for _, n := range l {
+		if _, found := t[n.Tag]; !found {
+			t[n.Tag] = n.Value
+		}
+	}
+
+	return t
+}
+
+func (n Node) Encode() []byte {
+	header := n.decodedHeader()
+
+	if header == nil {
+		header = append(EncodeTag(n.Tag), EncodeLength(uint64(len(n.Value)))...)
+	}
+
+	data := make([]byte, 0, len(header)+len(n.Value))
+	data = append(data, header...)
+	data = append(data, n.Value...)
+
+	return data
+}
+
+// decodedHeader returns the header the node was decoded with, or nil if the
+// node has none or its tag or value length changed since.
+func (n Node) decodedHeader() []byte {
+	if n.header == nil {
+		return nil
+	}
+
+	tag, tagLength, err := DecodeTag(n.header)
+
+	if err != nil || tag != n.Tag {
+		return nil
+	}
+
+	length, _, err := DecodeLength(n.header[tagLength:])
+
+	if err != nil || length != uint64(len(n.Value)) {
+		return nil
+	}
+
+	return n.header
+}
@@ -0,0 +1,58 @@
+package tlv
+
+import (
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestList(t *testing.T) {
+	data, err := hex.DecodeString("9f180401020304860584240000008605841e000000" + "5f2081054a4f484e20" + "9f360200419f2701")
+	assert.Nil(t, err)
+
+	_, err = DecodeList(data)
+	assert.NotNil(t, err)
+
+	data = append(data, 0x80)
+
+	list, err := DecodeList(data)
+	assert.Nil(t, err)
+	assert.Len(t, list, 6)
+	assert.Equal(t, data, list.Encode())
+
+	assert.Len(t, list.FindAll(0x86), 2)
+
+	value, found := list.Find(0x86)
+	assert.True(t, found)
+	assert.Equal(t, []byte{0x84, 0x24, 0x00, 0x00, 0x00}, value)
+
+	_, found = list.Without(0x86).Find(0x86)
+	assert.False(t, found)
+
+	assert.Equal(t, Tlv{
+		0x9F18: {0x01, 0x02, 0x03, 0x04},
+		0x86:   {0x84, 0x24, 0x00, 0x00, 0x00},
+		0x5F20: []byte("JOHN "),
+		0x9F36: {0x00, 0x41},
+		0x9F27: {0x80},
+	}, list.Map())
+
+	list.Append(0x9F37, []byte{0x01, 0x02, 0x03, 0x04})
+	assert.Equal(t, append(data, 0x9F, 0x37, 0x04, 0x01, 0x02, 0x03, 0x04), list.Encode())
+}
+
+func TestListModifiedNode(t *testing.T) {
+	data := []byte{0x5F, 0x20, 0x81, 0x04, 'J', 'O', 'H', 'N'}
+
+	list, err := DecodeList(data)
+	assert.Nil(t, err)
+
+	data[4] = 'X'
+	assert.Equal(t, []byte("JOHN"), list[0].Value)
+
+	list[0].Value = []byte("JANE")
+	assert.Equal(t, []byte{0x5F, 0x20, 0x81, 0x04, 'J', 'A', 'N', 'E'}, list.Encode())
+
+	list[0].Value = []byte("JOHN DOE")
+	assert.Equal(t, append([]byte{0x5F, 0x20, 0x08}, "JOHN DOE"...), list.Encode())
+}
@@ -0,0 +1,114 @@
+package tlv
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EncodeNumeric encodes a string of digits as n, right justified with a
+// leading zero when the number of digits is odd.
+func EncodeNumeric(digits string) ([]byte, error) {
+	if !isDigits(digits) {
+		return nil, fmt.Errorf("invalid numeric value %q", digits)
+	}
+
+	if len(digits)%2 != 0 {
+		digits = "0" + digits
+	}
+
+	return hex.DecodeString(digits)
+}
+
+// DecodeNumeric decodes an n value to its digits, keeping leading zeroes.
+func DecodeNumeric(data []byte) (string, error) {
+	for i, c := range data {
+		if c>>4 > 9 || c&0x0F > 9 {
+			return "", &DecodeError{Offset: i, Err: ErrInvalidFormat}
+		}
+	}
+
+	return hex.EncodeToString(data), nil
+}
+
+// EncodeCompressedNumeric encodes a string of digits as cn, left justified
+// with a trailing F when the number of digits is odd.
+func EncodeCompressedNumeric(digits string) ([]byte, error) {
+	if !isDigits(digits) {
+		return nil, fmt.Errorf("invalid numeric value %q", digits)
+	}
+
+	if len(digits)%2 != 0 {
+		digits += "f"
+	}
+
+	return hex.DecodeString(digits)
+}
+
+// DecodeCompressedNumeric decodes a cn value to its digits, without the
+// trailing F padding.
+func DecodeCompressedNumeric(data []byte) (string, error) {
+	padding := false
+
+	for i, c := range data {
+		for _, digit := range []byte{c >> 4, c & 0x0F} {
+			if digit == 0x0F {
+				padding = true
+			} else if padding || digit > 9 {
+				return "", &DecodeError{Offset: i, Err: ErrInvalidFormat}
+			}
+		}
+	}
+
+	return strings.TrimRight(hex.EncodeToString(data), "f"), nil
+}
+
+// EncodeDate encodes date as n YYMMDD.
+func EncodeDate(date time.Time) []byte {
+	data, _ := EncodeNumeric(date.Format("060102"))
+
+	return data
+}
+
+// DecodeDate decodes an n YYMMDD date, where years below 50 are in the 21st
+// century (EMV Book 4, section 6.7.3).
+func DecodeDate(data []byte) (time.Time, error) {
+	if len(data) != 3 {
+		return time.Time{}, &DecodeError{Offset: len(data), Err: ErrInvalidFormat}
+	}
+
+	digits, err := DecodeNumeric(data)
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	year := int(digits[0]-'0')*10 + int(digits[1]-'0')
+	month := time.Month(int(digits[2]-'0')*10 + int(digits[3]-'0'))
+	day := int(digits[4]-'0')*10 + int(digits[5]-'0')
+
+	if year < 50 {
+		year += 2000
+	} else {
+		year += 1900
+	}
+
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	if date.Month() != month || date.Day() != day {
+		return time.Time{}, &DecodeError{Offset: 1, Err: ErrInvalidFormat}
+	}
+
+	return date, nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,122 @@
+package tlv
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// options are the struct tag options after the tag, eg. `tlv:"9F02,n,len=6"`:
+//
+//	hex        strings hold the value hex encoded
+//	n, cn      numeric and compressed numeric BCD values
+//	an, ans    alphanumeric (special) strings
+//	len=N      fixed length in bytes, padding shorter values
+//	left       pad on the left (the default for n and binary integers)
+//	right      pad on the right (the default for cn, an and ans)
+//	omitempty  don't marshal zero values
+//
+// time.Time values are encoded as n YYMMDD.
+type options struct {
+	hex       bool
+	format    Format
+	length    int
+	padLeft   bool
+	omitEmpty bool
+}
+
+func parseOptions(opts []string) (options, error) {
+	o := options{}
+	side := ""
+
+	for _, opt := range opts {
+		switch {
+		case opt == "hex":
+			o.hex = true
+		case opt == "omitempty":
+			o.omitEmpty = true
+		case opt == "n":
+			o.format = FormatN
+		case opt == "cn":
+			o.format = FormatCn
+		case opt == "an":
+			o.format = FormatAn
+		case opt == "ans":
+			o.format = FormatAns
+		case opt == "left", opt == "right":
+			side = opt
+		case strings.HasPrefix(opt, "len="):
+			length, err := strconv.Atoi(opt[4:])
+
+			if err != nil || length <= 0 {
+				return o, fmt.Errorf("invalid length option %s", opt)
+			}
+
+			o.length = length
+		}
+	}
+
+	switch side {
+	case "left":
+		o.padLeft = true
+	case "right":
+		o.padLeft = false
+	default:
+		o.padLeft = o.format == FormatN || o.format == 0
+	}
+
+	return o, nil
+}
+
+// padding returns the byte used to pad values of the format.
+func (o options) padding() byte {
+	if o.format == FormatCn {
+		return 0xFF
+	}
+
+	return 0x00
+}
+
+// pad pads data to the fixed length, if any.
+func (o options) pad(data []byte) ([]byte, error) {
+	if o.length == 0 || len(data) == o.length {
+		return data, nil
+	}
+
+	if len(data) > o.length {
+		return nil, fmt.Errorf("value %X longer than %d bytes", data, o.length)
+	}
+
+	padding := make([]byte, o.length-len(data))
+
+	for i := range padding {
+		padding[i] = o.padding()
+	}
+
+	if o.padLeft {
+		return append(padding, data...), nil
+	}
+
+	return append(data, padding...), nil
+}
+
+// unpad removes the padding of fixed length alphanumeric values.
+func (o options) unpad(data []byte) []byte {
+	if o.length == 0 || o.format != FormatAn && o.format != FormatAns {
+		return data
+	}
+
+	i, j := 0, len(data)
+
+	if o.padLeft {
+		for i < j && data[i] == o.padding() {
+			i++
+		}
+	} else {
+		for j > i && data[j-1] == o.padding() {
+			j--
+		}
+	}
+
+	return data[i:j]
+}
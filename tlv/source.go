@@ -0,0 +1,25 @@
+package tlv
+
+// Source is where the value of a data element comes from.
+type Source int
+
+const (
+	_ Source = iota
+
+	SourceIcc
+	SourceTerminal
+	SourceIssuer
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceIcc:
+		return "ICC"
+	case SourceTerminal:
+		return "Terminal"
+	case SourceIssuer:
+		return "Issuer"
+	}
+
+	return "Unknown"
+}
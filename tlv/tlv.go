@@ -29,32 +29,14 @@ func (tlv Tlv) CopyFrom(other Tlv) {
 }
 
 func (tlv Tlv) DecodeTlv(data []byte) error {
-	for i := 0; i < len(data); {
-		tag, tagLength, err := DecodeTag(data[i:])
+	list, err := DecodeList(data)
 
-		if err != nil {
-			return err
-		}
-
-		i += tagLength
-
-		if tag == 0 {
-			continue
-		}
-
-		length, lengthLength, err := DecodeLength(data[i:])
-
-		if err != nil {
-			return err
-		}
-
-		i += lengthLength
-
-		value := make([]byte, int(length))
-		copy(value, data[i:i+int(length)])
-		i += int(length)
+	if err != nil {
+		return err
+	}
 
-		tlv[tag] = value
+	for _, n := range list {
+		tlv[n.Tag] = n.Value
 	}
 
 	return nil
@@ -106,6 +88,16 @@ func (t Tlv) Marshal(obj interface{}) error {
 				return err
 			}
 
+			o, err := parseOptions(opts)
+
+			if err != nil {
+				return err
+			}
+
+			if o.omitEmpty && field.IsZero() {
+				continue
+			}
+
 			err = t.MarshalValueWithOptions(int(tag), field.Interface(), opts)
 
 			if err != nil {
@@ -196,6 +188,24 @@ func (t Tlv) MarshalValueWithOptions(tag int, value interface{}, options []strin
 		return nil
 	}
 
+	o, err := parseOptions(options)
+
+	if err != nil {
+		return err
+	}
+
+	if o.formatted(value) {
+		data, err := encodeFormatted(value, o)
+
+		if err != nil {
+			return err
+		}
+
+		t[tag] = data
+
+		return nil
+	}
+
 	switch typ.Kind() {
 	case reflect.Struct:
 		tlv := make(Tlv)
@@ -257,7 +267,15 @@ func (t Tlv) UnmarshalValue(tag int, value interface{}) (bool, error) {
 	return t.UnmarshalValueWithOptions(tag, value, []string{})
 }
 
+// UnmarshalValueWithOptions decodes the value of tag into value. Decoding
+// errors have tag added to their path.
 func (t Tlv) UnmarshalValueWithOptions(tag int, value interface{}, options []string) (bool, error) {
+	found, err := t.unmarshalValue(tag, value, options)
+
+	return found, NestError(err, tag)
+}
+
+func (t Tlv) unmarshalValue(tag int, value interface{}, options []string) (bool, error) {
 	data, found := t[tag]
 
 	if !found {
@@ -283,6 +301,16 @@ func (t Tlv) UnmarshalValueWithOptions(tag int, value interface{}, options []str
 		return true, decoder.DecodeTlv(data)
 	}
 
+	o, err := parseOptions(options)
+
+	if err != nil {
+		return true, err
+	}
+
+	if o.formatted(value) {
+		return true, decodeFormatted(data, value, o)
+	}
+
 	switch typ.Kind() {
 	case reflect.Struct:
 		result, err := DecodeTlv(data)
@@ -415,6 +443,32 @@ func DecodeTlv(data []byte) (Tlv, error) {
 	return tlv, tlv.DecodeTlv(data)
 }
 
+func EncodeTlv(tlv Tlv) []byte {
+	data, _ := tlv.EncodeTlv()
+
+	return data
+}
+
+// Validate checks the length and format of the data objects found in the
+// dictionary, other data objects are accepted as is.
+func (t Tlv) Validate() error {
+	for tag, value := range t {
+		e, found := Lookup(tag)
+
+		if !found {
+			continue
+		}
+
+		err := e.Validate(value)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func optsContains(s []string, e string) bool {
 	for _, a := range s {
 		if a == e {
@@ -3,7 +3,9 @@ package main
 import (
 	"fmt"
 	"github.com/greenboxal/emv-kernel/emv"
+	"github.com/greenboxal/emv-kernel/tlv"
 	"sort"
+	"time"
 )
 
 type terminalPinAsker struct{}
@@ -20,22 +22,22 @@ func (t *terminalPinAsker) RetrievePin() (string, error) {
 }
 
 type TransactionProcessor struct {
-	card *emv.Card
-	ctx  *emv.Context
+	card      *emv.Card
+	transport emv.Transport
+	config    *emv.ContextConfig
+	ctx       *emv.Context
 }
 
-func NewTransactionProcessor(card *emv.Card) *TransactionProcessor {
+func NewTransactionProcessor(card *emv.Card, transport emv.Transport, config *emv.ContextConfig) *TransactionProcessor {
 	return &TransactionProcessor{
-		card: card,
+		card:      card,
+		transport: transport,
+		config:    config,
 	}
 }
 
 func (t *TransactionProcessor) Initialize() error {
-	t.ctx = emv.NewContext(t.card, &emv.ContextConfig{
-		Terminal: emv.Terminal{
-			CountryCode: []byte{0x00, 0x76},
-		},
-	}, &fileCertificateManager{"./certs"})
+	t.ctx = emv.NewContext(t.card, t.config, &fileCertificateManager{"./certs"})
 
 	err := t.ctx.Initialize()
 
@@ -55,8 +57,17 @@ func (t *TransactionProcessor) Initialize() error {
 		return err
 	}
 
-	raw, _ := t.ctx.CardInformation.Raw.EncodeTlv()
-	fmt.Printf("%x\n", raw)
+	dump, err := tlv.Dump(t.ctx.RecordData.Encode())
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(dump)
+
+	for _, err := range t.ctx.ValidationErrors() {
+		fmt.Printf("Warning: %v\n", err)
+	}
 
 	_, err = t.ctx.Authenticate()
 
@@ -111,6 +122,91 @@ func (t *TransactionProcessor) selectApplication() (*emv.ApplicationInformation,
 	return app, nil
 }
 
+// readTransaction asks for the transaction amount, or takes the transaction
+// from the trace when replaying one.
+func (t *TransactionProcessor) readTransaction() (*emv.Transaction, error) {
+	if replayer, ok := t.transport.(*emv.TraceReplayer); ok {
+		tx := replayer.Transaction()
+
+		if tx == nil {
+			return nil, fmt.Errorf("trace has no transaction")
+		}
+
+		return tx, nil
+	}
+
+	amount := 0
+
+	fmt.Printf("Amount (in cents): ")
+	fmt.Scanf("%d\n", &amount)
+
+	tx := &emv.Transaction{
+		Date:   time.Now(),
+		Amount: amount,
+	}
+
+	if recorder, ok := t.transport.(*emv.TraceRecorder); ok {
+		recorder.RecordTransaction(tx)
+	}
+
+	return tx, nil
+}
+
 func (t *TransactionProcessor) Process() error {
+	tx, err := t.readTransaction()
+
+	if err != nil {
+		return err
+	}
+
+	err = t.ctx.ProcessRestrictions(tx)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = t.ctx.VerifyCardholder(tx, &terminalPinAsker{})
+
+	if err != nil {
+		return err
+	}
+
+	err = t.ctx.ManageRisk(tx)
+
+	if err != nil {
+		return err
+	}
+
+	result, err := t.ctx.GenerateCryptogram(tx)
+
+	if err != nil {
+		return err
+	}
+
+	if result.ShouldGoOnline {
+		fmt.Printf("ARQC %x\n", result.Cryptogram)
+
+		// There's no acquirer to send the ARQC to
+		result, err = t.ctx.ProcessOnlineResponse(tx, nil)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("TVR %x: %s\n", t.ctx.Tvr().Bytes(), t.ctx.Tvr())
+	fmt.Printf("TSI %x: %s\n", t.ctx.Tsi().Bytes(), t.ctx.Tsi())
+	fmt.Printf("CVM Results %x: %s\n", t.ctx.CvmResults().Bytes(), t.ctx.CvmResults())
+
+	if result.Approved {
+		fmt.Printf("Transaction approved (TC %x)\n", result.Cryptogram)
+
+		if result.SignatureRequired {
+			fmt.Printf("Cardholder signature required\n")
+		}
+	} else {
+		fmt.Printf("Transaction declined (AAC %x)\n", result.Cryptogram)
+	}
+
 	return nil
 }